@@ -0,0 +1,67 @@
+// Command create-api-key issues an API key directly in the api_keys table,
+// e.g. to bootstrap the first admin key before any caller can use the
+// /admin/api-keys endpoints. It reads the same environment as the API
+// (AWS_REGION, DYNAMODB_ENDPOINT, TABLE_API_KEYS) and prints the plaintext
+// key, which cannot be retrieved later.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/config"
+	"go-serverless-api-terraform/internal/db"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+func main() {
+	name := flag.String("name", "", "human-readable key name (required)")
+	owner := flag.String("owner", "", "subject requests made with the key act as (required)")
+	scopes := flag.String("scopes", "admin", "comma-separated roles: admin, support, customer")
+	tenantID := flag.String("tenant", "", "bind the key to this tenant (multi-tenant deployments)")
+	ttl := flag.Duration("ttl", 0, "expire the key after this duration (default: never)")
+	flag.Parse()
+	if *name == "" || *owner == "" {
+		flag.Usage()
+		log.Fatal("-name and -owner are required")
+	}
+	k := apikeys.Key{Name: *name, OwnerID: *owner, TenantID: *tenantID, Scopes: strings.Split(*scopes, ",")}
+	if k.TenantID != "" && !tenant.Valid(k.TenantID) {
+		log.Fatalf("invalid tenant %q", k.TenantID)
+	}
+	for _, s := range k.Scopes {
+		if !apikeys.ValidScope(s) {
+			log.Fatalf("unknown scope %q", s)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	ctx := context.Background()
+	dynamo, err := db.NewDynamoClient(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create dynamodb client: %v", err)
+	}
+
+	var plaintext string
+	if k.ID, plaintext, k.Hash, err = apikeys.New(); err != nil {
+		log.Fatalf("failed to generate key: %v", err)
+	}
+	now := time.Now().UTC()
+	k.CreatedAt = now.Format(time.RFC3339)
+	if *ttl > 0 {
+		k.ExpiresAt = now.Add(*ttl).Format(time.RFC3339)
+	}
+	if err := apikeys.NewDynamoStore(dynamo, cfg.APIKeysTable).Create(ctx, &k); err != nil {
+		log.Fatalf("failed to store key: %v", err)
+	}
+	log.Printf("created api key %s for %s with scopes %s", k.ID, k.OwnerID, strings.Join(k.Scopes, ","))
+	fmt.Println(plaintext)
+}
@@ -0,0 +1,41 @@
+// Command migrate-money rewrites legacy float prices and order totals into
+// the exact models.Money representation. It reads the same environment as
+// the API (AWS_REGION, DYNAMODB_ENDPOINT, TABLE_ORDERS, TABLE_ORDER_ITEMS,
+// and OUTBOX_ENABLED and TABLE_OUTBOX for the events of repaired orders).
+package main
+
+import (
+	"context"
+	"log"
+
+	"go-serverless-api-terraform/internal/config"
+	"go-serverless-api-terraform/internal/db"
+	"go-serverless-api-terraform/internal/repository"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	dynamo, err := db.NewDynamoClient(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create dynamodb client: %v", err)
+	}
+
+	outboxTable := ""
+	if cfg.OutboxEnabled {
+		outboxTable = cfg.OutboxTable
+	}
+	repo := repository.NewDynamoRepository(dynamo, cfg.OrdersTable, cfg.OrderItemsTable, cfg.OrderEventsTable, outboxTable)
+	stats, err := repo.MigrateLegacyMoney(ctx)
+	if err != nil {
+		log.Fatalf("migration stopped after %d items and %d orders: %v", stats.Items, stats.Orders, err)
+	}
+	log.Printf("migrated %d items and %d orders", stats.Items, stats.Orders)
+	if stats.Conflicts > 0 {
+		log.Printf("skipped %d records that changed during the migration; run it again to migrate them", stats.Conflicts)
+	}
+}
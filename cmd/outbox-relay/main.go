@@ -0,0 +1,68 @@
+// Command outbox-relay publishes the domain events in the outbox table with
+// the publisher selected by OUTBOX_PUBLISHER. It reads the same environment
+// as the API. Locally it drains the outbox every OUTBOX_POLL_INTERVAL until
+// interrupted; with APP_ENV=lambda each invocation, e.g. from an EventBridge
+// schedule, drains it once.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"go-serverless-api-terraform/internal/config"
+	"go-serverless-api-terraform/internal/db"
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/outbox"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+	if cfg.Storage != "dynamo" {
+		fatal("invalid configuration", errors.New("the relay needs STORAGE=dynamo; the API relays in-memory outboxes itself"))
+	}
+
+	ctx := context.Background()
+	pub, err := outbox.NewPublisher(ctx, cfg)
+	if err != nil {
+		fatal("failed to configure outbox publisher", err)
+	}
+	if pub == nil {
+		fatal("invalid configuration", errors.New("OUTBOX_PUBLISHER is required"))
+	}
+	dynamo, err := db.NewDynamoClient(ctx, cfg)
+	if err != nil {
+		fatal("failed to create dynamodb client", err)
+	}
+	relay := outbox.NewRelay(outbox.NewDynamoStore(dynamo, cfg.OutboxTable), pub, cfg.OutboxPollInterval, cfg.OutboxMaxAttempts)
+
+	if cfg.Env == "lambda" {
+		lambda.Start(func(ctx context.Context) (int, error) {
+			return relay.Drain(ctx)
+		})
+		return
+	}
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	slog.Info("relaying outbox", "publisher", cfg.OutboxPublisher, "table", cfg.OutboxTable)
+	relay.Run(sigCtx)
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
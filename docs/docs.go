@@ -17,25 +17,43 @@ func (s *swaggerDoc) ReadDoc() string {
 	  "swagger": "2.0",
 	  "info": {
 	    "title": "Orders API",
-	    "description": "API for managing orders and order items",
+	    "description": "API for managing orders and order items. In multi-tenant deployments, the tenant is the one the token or API key is bound to; only admins not bound to a tenant may name one with the X-Tenant-ID header (or a subdomain). The unversioned paths (e.g. /orders) are deprecated aliases of /v1 and respond with Deprecation and Sunset headers.",
 	    "version": "1.0"
 	  },
 	  "basePath": "/",
 	  "schemes": ["https", "http"],
+	  "securityDefinitions": {
+	    "BearerAuth": {"type": "apiKey", "in": "header", "name": "Authorization", "description": "JWT as \"Bearer <token>\"; 401 with WWW-Authenticate when missing or invalid"},
+	    "ApiKeyAuth": {"type": "apiKey", "in": "header", "name": "X-API-Key", "description": "API key issued via /admin/api-keys, as ak_<id>.<secret>"}
+	  },
+	  "security": [{"BearerAuth": []}, {"ApiKeyAuth": []}],
 	  "paths": {
-	    "/orders": {
+	    "/v1/orders": {
 	      "get": {
 	        "summary": "List orders",
+	        "description": "Filtering by owner_id, status or customer_name uses an index and allows sorting by created_at; pass the same filters with cursor",
+	        "parameters": [
+	          {"name":"status","in":"query","required":false,"type":"string","enum":["new","confirmed","paid","shipped","delivered","cancelled","refunded"]},
+	          {"name":"customer_name","in":"query","required":false,"type":"string"},
+	          {"name":"owner_id","in":"query","required":false,"type":"string","description":"Admin and support only; customers always see only their own orders"},
+	          {"name":"created_from","in":"query","required":false,"type":"string","format":"date-time","description":"Inclusive lower bound on created_at"},
+	          {"name":"created_to","in":"query","required":false,"type":"string","format":"date-time","description":"Inclusive upper bound on created_at"},
+	          {"name":"sort","in":"query","required":false,"type":"string","enum":["created_at","-created_at"],"description":"Requires owner_id, status or customer_name"},
+	          {"name":"limit","in":"query","required":false,"type":"integer","description":"Page size (1-100, default 50)"},
+	          {"name":"cursor","in":"query","required":false,"type":"string","description":"Opaque cursor from a previous response"}
+	        ],
 	        "responses": {
 	          "200": {
 	            "description": "OK",
-	            "schema": {"type": "array", "items": {"$ref": "#/definitions/models.Order"}}
-	          }
+	            "schema": {"$ref": "#/definitions/handlers.ordersPage"}
+	          },
+	          "400": {"description": "Bad Request"}
 	        }
 	      },
 	      "post": {
 	        "summary": "Create order",
 	        "parameters": [
+	          {"name":"Idempotency-Key","in":"header","required":false,"type":"string","description":"Replays the stored response for retries of the same request"},
 	          {
 	            "in": "body",
 	            "name": "order",
@@ -49,41 +67,92 @@ func (s *swaggerDoc) ReadDoc() string {
 	        }
 	      }
 	    },
-	    "/orders/{orderId}": {
+	    "/v1/orders/{orderId}": {
 	      "parameters": [{"name":"orderId","in":"path","required":true,"type":"string"}],
 	      "get": {
 	        "summary": "Get order",
+	        "description": "The ETag carries the order's version, which item creates, updates and deletes also bump, since they change the order's totals",
 	        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/models.Order"}}}
 	      },
 	      "put": {
 	        "summary": "Update order",
+	        "description": "Send If-Match with the ETag from a previous read. Item changes since that read also make it stale (412), as they update the order's totals.",
 	        "parameters": [
 	          {"name":"orderId","in":"path","required":true,"type":"string"},
+	          {"name":"If-Match","in":"header","required":false,"type":"string","description":"ETag of the version being updated"},
 	          {"in": "body", "name": "order", "required": true, "schema": {"$ref": "#/definitions/handlers.updateOrderReq"}}
 	        ],
-	        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/models.Order"}}}
+	        "responses": {
+	          "200": {"description": "OK", "schema": {"$ref": "#/definitions/models.Order"}},
+	          "412": {"description": "Precondition Failed"}
+	        }
 	      },
 	      "delete": {
 	        "summary": "Delete order",
-	        "responses": {"204": {"description": "No Content"}}
+	        "description": "Deletes the order and all of its items. Only the order's deletion is recorded in its history and events.",
+	        "responses": {"204": {"description": "No Content"}, "409": {"description": "Items kept changing during the delete; retry"}}
+	      }
+	    },
+	    "/v1/orders/{orderId}/transitions": {
+	      "post": {
+	        "summary": "Change order status",
+	        "description": "Allowed: new -> confirmed|cancelled, confirmed -> paid|cancelled, paid -> shipped|refunded, shipped -> delivered, delivered -> refunded",
+	        "parameters": [
+	          {"name":"orderId","in":"path","required":true,"type":"string"},
+	          {"name":"If-Match","in":"header","required":false,"type":"string","description":"ETag of the version being updated"},
+	          {"name":"Idempotency-Key","in":"header","required":false,"type":"string","description":"Replays the stored response for retries of the same request"},
+	          {"in": "body", "name": "transition", "required": true, "schema": {"$ref": "#/definitions/handlers.transitionReq"}}
+	        ],
+	        "responses": {
+	          "200": {"description": "OK", "schema": {"$ref": "#/definitions/models.Order"}},
+	          "400": {"description": "Bad Request"},
+	          "404": {"description": "Not Found"},
+	          "409": {"description": "Illegal transition or concurrent status change"},
+	          "412": {"description": "Precondition Failed"}
+	        }
+	      }
+	    },
+	    "/v1/orders/{orderId}/history": {
+	      "get": {
+	        "summary": "Get order history",
+	        "description": "Audit events for the order and its items, oldest first. Admins and support can read the history of deleted orders.",
+	        "parameters": [
+	          {"name":"orderId","in":"path","required":true,"type":"string"},
+	          {"name":"limit","in":"query","required":false,"type":"integer","description":"Page size (1-100, default 50)"},
+	          {"name":"cursor","in":"query","required":false,"type":"string","description":"Opaque cursor from a previous response"}
+	        ],
+	        "responses": {
+	          "200": {"description": "OK", "schema": {"$ref": "#/definitions/handlers.historyPage"}},
+	          "400": {"description": "Bad Request"},
+	          "404": {"description": "Not Found"}
+	        }
 	      }
 	    },
-	    "/orders/{orderId}/items": {
+	    "/v1/orders/{orderId}/items": {
 	      "parameters": [{"name":"orderId","in":"path","required":true,"type":"string"}],
 	      "get": {
 	        "summary": "List items",
-	        "responses": {"200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/models.OrderItem"}}}}
+	        "parameters": [
+	          {"name":"orderId","in":"path","required":true,"type":"string"},
+	          {"name":"limit","in":"query","required":false,"type":"integer","description":"Page size (1-100, default 50)"},
+	          {"name":"cursor","in":"query","required":false,"type":"string","description":"Opaque cursor from a previous response"}
+	        ],
+	        "responses": {
+	          "200": {"description": "OK", "schema": {"$ref": "#/definitions/handlers.itemsPage"}},
+	          "400": {"description": "Bad Request"}
+	        }
 	      },
 	      "post": {
 	        "summary": "Create item",
 	        "parameters": [
 	          {"name":"orderId","in":"path","required":true,"type":"string"},
+	          {"name":"Idempotency-Key","in":"header","required":false,"type":"string","description":"Replays the stored response for retries of the same request"},
 	          {"in": "body", "name": "item", "required": true, "schema": {"$ref": "#/definitions/handlers.createItemReq"}}
 	        ],
-	        "responses": {"201": {"description": "Created", "schema": {"$ref": "#/definitions/models.OrderItem"}}}
+	        "responses": {"201": {"description": "Created", "schema": {"$ref": "#/definitions/models.OrderItem"}}, "422": {"description": "Line or order total exceeds the maximum amount"}}
 	      }
 	    },
-	    "/orders/{orderId}/items/{itemId}": {
+	    "/v1/orders/{orderId}/items/{itemId}": {
 	      "parameters": [
 	        {"name":"orderId","in":"path","required":true,"type":"string"},
 	        {"name":"itemId","in":"path","required":true,"type":"string"}
@@ -94,22 +163,104 @@ func (s *swaggerDoc) ReadDoc() string {
 	        "parameters": [
 	          {"name":"orderId","in":"path","required":true,"type":"string"},
 	          {"name":"itemId","in":"path","required":true,"type":"string"},
+	          {"name":"If-Match","in":"header","required":false,"type":"string","description":"ETag of the version being updated"},
 	          {"in": "body", "name": "item", "required": true, "schema": {"$ref": "#/definitions/handlers.updateItemReq"}}
 	        ],
-	        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/models.OrderItem"}}}
+	        "responses": {
+	          "200": {"description": "OK", "schema": {"$ref": "#/definitions/models.OrderItem"}},
+	          "412": {"description": "Precondition Failed"},
+	          "422": {"description": "Line or order total exceeds the maximum amount"}
+	        }
 	      },
 	      "delete": {"summary": "Delete item", "responses": {"204": {"description": "No Content"}}}
+	    },
+	    "/v1/admin/api-keys": {
+	      "get": {
+	        "summary": "List API keys (admin)",
+	        "responses": {"200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/apikeys.Key"}}}, "403": {"description": "Forbidden"}}
+	      },
+	      "post": {
+	        "summary": "Create API key (admin)",
+	        "description": "The plaintext key is returned once in the key member and cannot be retrieved later",
+	        "parameters": [{"in": "body", "name": "key", "required": true, "schema": {"$ref": "#/definitions/handlers.createAPIKeyReq"}}],
+	        "responses": {"201": {"description": "Created", "schema": {"$ref": "#/definitions/handlers.apiKeyResp"}}, "400": {"description": "Bad Request"}, "403": {"description": "Forbidden"}}
+	      }
+	    },
+	    "/v1/admin/api-keys/{keyId}/rotate": {
+	      "parameters": [{"name":"keyId","in":"path","required":true,"type":"string"}],
+	      "post": {
+	        "summary": "Rotate API key secret (admin)",
+	        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/handlers.apiKeyResp"}}, "404": {"description": "Not Found"}, "409": {"description": "Key is revoked"}}
+	      }
+	    },
+	    "/v1/admin/api-keys/{keyId}": {
+	      "parameters": [{"name":"keyId","in":"path","required":true,"type":"string"}],
+	      "delete": {"summary": "Revoke API key (admin)", "responses": {"204": {"description": "No Content"}, "404": {"description": "Not Found"}}}
+	    },
+	    "/healthz": {
+	      "get": {"summary": "Liveness probe", "security": [], "responses": {"200": {"description": "OK"}}}
+	    },
+	    "/readyz": {
+	      "get": {
+	        "summary": "Readiness probe",
+	        "description": "Checks both DynamoDB tables with DescribeTable (result cached for READINESS_CACHE_TTL) and reports their status and build info",
+	        "security": [],
+	        "responses": {"200": {"description": "Ready", "schema": {"$ref": "#/definitions/handlers.readinessResp"}}, "503": {"description": "A dependency is unavailable", "schema": {"$ref": "#/definitions/handlers.readinessResp"}}}
+	      }
 	    }
 	  },
 	  "definitions": {
+	    "handlers.readinessResp": {
+	      "type": "object",
+	      "properties": {
+	        "status": {"type": "string", "enum": ["ok", "unavailable"]},
+	        "checks": {"type": "object", "additionalProperties": {"type": "object", "properties": {"status": {"type": "string", "description": "DynamoDB table status, e.g. ACTIVE"}, "error": {"type": "string"}}}},
+	        "checked_at": {"type": "string", "format": "date-time"},
+	        "build": {"type": "object", "properties": {"version": {"type": "string"}, "commit": {"type": "string"}, "go_version": {"type": "string"}}}
+	      }
+	    },
+	    "apikeys.Key": {
+	      "type": "object",
+	      "properties": {
+	        "id": {"type": "string"},
+	        "name": {"type": "string"},
+	        "owner_id": {"type": "string", "description": "Subject requests made with the key act as"},
+	        "scopes": {"type": "array", "items": {"type": "string", "enum": ["admin", "support", "customer"]}},
+	        "created_at": {"type": "string"},
+	        "rotated_at": {"type": "string"},
+	        "expires_at": {"type": "string"},
+	        "revoked_at": {"type": "string"}
+	      }
+	    },
+	    "handlers.apiKeyResp": {
+	      "allOf": [
+	        {"$ref": "#/definitions/apikeys.Key"},
+	        {"type": "object", "properties": {"key": {"type": "string", "description": "Plaintext key, shown only once", "example": "ak_3f9c0d1e2a4b5c6d7e.s3cr3t"}}}
+	      ]
+	    },
+	    "handlers.createAPIKeyReq": {
+	      "type": "object",
+	      "required": ["name", "scopes"],
+	      "properties": {
+	        "name": {"type": "string"},
+	        "owner_id": {"type": "string", "description": "Defaults to the caller"},
+	        "scopes": {"type": "array", "items": {"type": "string", "enum": ["admin", "support", "customer"]}},
+	        "expires_at": {"type": "string", "format": "date-time"}
+	      }
+	    },
 	    "models.Order": {
 	      "type": "object",
 	      "properties": {
 	        "id": {"type": "string", "example": "b5e1c2f4-1234-4a7e-8c1a-abcdef012345"},
 	        "customer_name": {"type": "string", "example": "Alice"},
-	        "status": {"type": "string", "example": "new"},
+	        "owner_id": {"type": "string", "description": "Subject of the caller that created the order"},
+	        "status": {"type": "string", "enum": ["new", "confirmed", "paid", "shipped", "delivered", "cancelled", "refunded"], "example": "new"},
 	        "created_at": {"type": "string", "example": "2024-01-01T12:00:00Z"},
-	        "updated_at": {"type": "string", "example": "2024-01-01T12:00:00Z"}
+	        "updated_at": {"type": "string", "example": "2024-01-01T12:00:00Z"},
+	        "item_count": {"type": "integer", "description": "Number of items in the order", "example": 1},
+	        "subtotal": {"$ref": "#/definitions/models.Money"},
+	        "total": {"$ref": "#/definitions/models.Money"},
+	        "version": {"type": "integer", "format": "int64", "example": 1}
 	      }
 	    },
 	    "models.OrderItem": {
@@ -119,9 +270,71 @@ func (s *swaggerDoc) ReadDoc() string {
 	        "id": {"type": "string", "example": "1f2e3d4c-5678-4b3a-9c0d-abcdef012345"},
 	        "product_name": {"type": "string", "example": "Keyboard"},
 	        "quantity": {"type": "integer", "format": "int32", "example": 2},
-	        "price": {"type": "number", "format": "double", "example": 99.99},
+	        "price": {"$ref": "#/definitions/models.Money"},
 	        "created_at": {"type": "string", "example": "2024-01-01T12:00:00Z"},
-	        "updated_at": {"type": "string", "example": "2024-01-01T12:00:00Z"}
+	        "updated_at": {"type": "string", "example": "2024-01-01T12:00:00Z"},
+	        "version": {"type": "integer", "format": "int64", "example": 1}
+	      }
+	    },
+	    "models.AuditEvent": {
+	      "type": "object",
+	      "properties": {
+	        "order_id": {"type": "string", "example": "b5e1c2f4-1234-4a7e-8c1a-abcdef012345"},
+	        "id": {"type": "string", "example": "0b6f7c1e-5d1a-4f59-9a53-2f7d3c1b8e42"},
+	        "at": {"type": "string", "description": "RFC 3339 with nanoseconds, from the clock of the instance that made the change", "example": "2024-01-01T12:00:00.123456789Z"},
+	        "actor": {"type": "string", "description": "Subject of the caller; omitted for changes made outside a request"},
+	        "operation": {"type": "string", "enum": ["create", "update", "delete"]},
+	        "item_id": {"type": "string", "description": "Set when an item changed rather than the order"},
+	        "changes": {"type": "array", "items": {"$ref": "#/definitions/models.FieldChange"}}
+	      }
+	    },
+	    "models.FieldChange": {
+	      "type": "object",
+	      "properties": {
+	        "field": {"type": "string", "example": "status"},
+	        "before": {"description": "Omitted for created fields", "example": "new"},
+	        "after": {"description": "Omitted for deleted fields", "example": "confirmed"}
+	      }
+	    },
+	    "models.Money": {
+	      "type": "object",
+	      "description": "Exact amount; amount is a decimal string in major units. On input a bare number is read in the order's currency.",
+	      "properties": {
+	        "amount": {"type": "string", "example": "99.99"},
+	        "currency": {"type": "string", "description": "ISO 4217 code", "example": "USD"}
+	      }
+	    },
+	    "problem.Details": {
+	      "type": "object",
+	      "description": "RFC 7807 problem details, served as application/problem+json",
+	      "properties": {
+	        "type": {"type": "string", "example": "urn:orders-api:problem:not_found"},
+	        "title": {"type": "string", "example": "Not Found"},
+	        "status": {"type": "integer", "example": 404},
+	        "detail": {"type": "string", "example": "resource not found"},
+	        "instance": {"type": "string", "example": "/v1/orders/b5e1c2f4-1234-4a7e-8c1a-abcdef012345"},
+	        "code": {"type": "string", "enum": ["invalid_request", "not_found", "already_exists", "conflict", "version_mismatch", "invalid_transition", "throttled", "unavailable", "partial_delete", "internal"]}
+	      }
+	    },
+	    "handlers.ordersPage": {
+	      "type": "object",
+	      "properties": {
+	        "items": {"type": "array", "items": {"$ref": "#/definitions/models.Order"}},
+	        "next_cursor": {"type": "string", "description": "Present when more results are available"}
+	      }
+	    },
+	    "handlers.historyPage": {
+	      "type": "object",
+	      "properties": {
+	        "items": {"type": "array", "items": {"$ref": "#/definitions/models.AuditEvent"}},
+	        "next_cursor": {"type": "string", "description": "Present when more results are available"}
+	      }
+	    },
+	    "handlers.itemsPage": {
+	      "type": "object",
+	      "properties": {
+	        "items": {"type": "array", "items": {"$ref": "#/definitions/models.OrderItem"}},
+	        "next_cursor": {"type": "string", "description": "Present when more results are available"}
 	      }
 	    },
 	    "handlers.createOrderReq": {
@@ -129,7 +342,9 @@ func (s *swaggerDoc) ReadDoc() string {
 	      "required": ["customer_name"],
 	      "properties": {
 	        "customer_name": {"type": "string"},
-	        "status": {"type": "string"}
+	        "status": {"type": "string", "enum": ["new"]},
+	        "owner_id": {"type": "string", "description": "Defaults to the caller; admins only for another owner"},
+	        "currency": {"type": "string", "description": "ISO 4217 code for all item prices (default USD)", "example": "USD"}
 	      }
 	    },
 	    "handlers.updateOrderReq": {
@@ -139,21 +354,28 @@ func (s *swaggerDoc) ReadDoc() string {
 	        "status": {"type": "string"}
 	      }
 	    },
+	    "handlers.transitionReq": {
+	      "type": "object",
+	      "required": ["status"],
+	      "properties": {
+	        "status": {"type": "string", "enum": ["confirmed", "paid", "shipped", "delivered", "cancelled", "refunded"]}
+	      }
+	    },
 	    "handlers.createItemReq": {
 	      "type": "object",
 	      "required": ["product_name", "quantity", "price"],
 	      "properties": {
 	        "product_name": {"type": "string"},
-	        "quantity": {"type": "integer", "format": "int32"},
-	        "price": {"type": "number", "format": "double"}
+	        "quantity": {"type": "integer", "format": "int32", "minimum": 1, "maximum": 1000000},
+	        "price": {"$ref": "#/definitions/models.Money"}
 	      }
 	    },
 	    "handlers.updateItemReq": {
 	      "type": "object",
 	      "properties": {
 	        "product_name": {"type": "string"},
-	        "quantity": {"type": "integer", "format": "int32"},
-	        "price": {"type": "number", "format": "double"}
+	        "quantity": {"type": "integer", "format": "int32", "minimum": 1, "maximum": 1000000},
+	        "price": {"$ref": "#/definitions/models.Money"}
 	      }
 	    }
 	  }
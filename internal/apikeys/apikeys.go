@@ -0,0 +1,120 @@
+// Package apikeys issues and verifies API keys for service-to-service callers.
+//
+// A key is shown to its owner once, as "ak_<id>.<secret>". Only a SHA-256
+// hash of the secret is stored; the id locates the record.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go-serverless-api-terraform/internal/auth"
+)
+
+// Errors returned by Store implementations.
+var (
+	ErrNotFound = errors.New("api key not found")
+	ErrRevoked  = errors.New("api key revoked")
+)
+
+const prefix = "ak_"
+
+// Key is a stored API key. Scopes are the auth roles granted to callers
+// using it (auth.RoleAdmin, auth.RoleSupport or auth.RoleCustomer).
+type Key struct {
+	ID        string   `json:"id" dynamodbav:"id"`
+	Name      string   `json:"name" dynamodbav:"name"`
+	OwnerID   string   `json:"owner_id" dynamodbav:"owner_id"`
+	TenantID  string   `json:"tenant_id,omitempty" dynamodbav:"tenant_id,omitempty"` // tenant the key is bound to, if any
+	Scopes    []string `json:"scopes" dynamodbav:"scopes,stringset"`
+	Hash      string   `json:"-" dynamodbav:"hash"` // hex SHA-256 of the secret
+	CreatedAt string   `json:"created_at" dynamodbav:"created_at"`
+	RotatedAt string   `json:"rotated_at,omitempty" dynamodbav:"rotated_at,omitempty"`
+	ExpiresAt string   `json:"expires_at,omitempty" dynamodbav:"expires_at,omitempty"` // RFC 3339; empty never expires
+	RevokedAt string   `json:"revoked_at,omitempty" dynamodbav:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *Key) Active(now time.Time) bool {
+	if k.RevokedAt != "" {
+		return false
+	}
+	if k.ExpiresAt == "" {
+		return true
+	}
+	exp, err := time.Parse(time.RFC3339, k.ExpiresAt)
+	return err == nil && now.Before(exp)
+}
+
+// Principal is the caller authenticated by k.
+func (k *Key) Principal() *auth.Principal {
+	return &auth.Principal{Subject: k.OwnerID, Roles: k.Scopes, Tenant: k.TenantID}
+}
+
+// ValidScope reports whether s is a role that can be granted to a key.
+func ValidScope(s string) bool {
+	return s == auth.RoleAdmin || s == auth.RoleSupport || s == auth.RoleCustomer
+}
+
+// Store persists API keys.
+type Store interface {
+	// Create stores a new key.
+	Create(ctx context.Context, k *Key) error
+	// Get returns the key with id, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Key, error)
+	// List returns every key, including revoked ones.
+	List(ctx context.Context) ([]Key, error)
+	// Rotate replaces the secret hash of an active key and returns the updated key.
+	Rotate(ctx context.Context, id, hash, rotatedAt string) (*Key, error)
+	// Revoke marks a key revoked; revoking twice is not an error.
+	Revoke(ctx context.Context, id, revokedAt string) error
+}
+
+// New generates an id and secret, returning the plaintext key and the
+// secret's hash.
+func New() (id, plaintext, hash string, err error) {
+	idb := make([]byte, 9)
+	if _, err := rand.Read(idb); err != nil {
+		return "", "", "", err
+	}
+	id = hex.EncodeToString(idb)
+	plaintext, hash, err = NewSecret(id)
+	return id, plaintext, hash, err
+}
+
+// NewSecret generates a fresh secret for an existing key id.
+func NewSecret(id string) (plaintext, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	return prefix + id + "." + secret, hashSecret(secret), nil
+}
+
+// parse splits a plaintext key into its id and secret.
+func parse(plaintext string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(plaintext, prefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, ".")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// matches compares secret with the stored hash in constant time.
+func (k *Key) matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(k.Hash)) == 1
+}
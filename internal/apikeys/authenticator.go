@@ -0,0 +1,99 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidKey is returned for malformed, unknown, revoked, expired or wrong keys.
+var ErrInvalidKey = errors.New("invalid api key")
+
+// maxCacheEntries bounds the cache of existing keys and maxMissEntries that
+// of unknown ids; each is cleared when full. Misses have their own cache so
+// callers sending made-up ids cannot flush the keys in use.
+const (
+	maxCacheEntries = 10000
+	maxMissEntries  = 1000
+)
+
+// Authenticator verifies plaintext keys, caching store lookups by id for ttl.
+// Revocations and rotations made through this process take effect at once;
+// those made elsewhere take up to ttl.
+type Authenticator struct {
+	store Store
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	cache  map[string]cacheEntry
+	misses map[string]time.Time // ids the store did not have, by when it was asked
+}
+
+type cacheEntry struct {
+	key     *Key
+	fetched time.Time
+}
+
+func NewAuthenticator(store Store, ttl time.Duration) *Authenticator {
+	return &Authenticator{store: store, ttl: ttl, cache: map[string]cacheEntry{}, misses: map[string]time.Time{}}
+}
+
+// Verify returns the active key matching plaintext, or ErrInvalidKey.
+// Store failures are returned as is.
+func (a *Authenticator) Verify(ctx context.Context, plaintext string) (*Key, error) {
+	id, secret, ok := parse(plaintext)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	k, err := a.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil || !k.matches(secret) || !k.Active(time.Now()) {
+		return nil, ErrInvalidKey
+	}
+	return k, nil
+}
+
+func (a *Authenticator) lookup(ctx context.Context, id string) (*Key, error) {
+	a.mu.Lock()
+	e, ok := a.cache[id]
+	missed, known := a.misses[id]
+	a.mu.Unlock()
+	if ok && time.Since(e.fetched) < a.ttl {
+		return e.key, nil
+	}
+	if known && time.Since(missed) < a.ttl {
+		return nil, nil
+	}
+	k, err := a.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		a.mu.Lock()
+		if len(a.misses) >= maxMissEntries {
+			clear(a.misses)
+		}
+		a.misses[id] = time.Now()
+		a.mu.Unlock()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	if len(a.cache) >= maxCacheEntries {
+		clear(a.cache)
+	}
+	a.cache[id] = cacheEntry{key: k, fetched: time.Now()}
+	delete(a.misses, id)
+	a.mu.Unlock()
+	return k, nil
+}
+
+// Forget drops id from the cache, e.g. after it was rotated or revoked.
+func (a *Authenticator) Forget(id string) {
+	a.mu.Lock()
+	delete(a.cache, id)
+	delete(a.misses, id)
+	a.mu.Unlock()
+}
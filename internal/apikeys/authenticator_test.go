@@ -0,0 +1,53 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countingStore counts lookups.
+type countingStore struct {
+	Store
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) (*Key, error) {
+	s.gets++
+	return s.Store.Get(ctx, id)
+}
+
+func TestAuthenticatorMissesKeepKeysCached(t *testing.T) {
+	ctx := context.Background()
+	id, plaintext, hash, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &countingStore{Store: NewMemoryStore()}
+	if err := store.Create(ctx, &Key{ID: id, Name: "svc", Scopes: []string{"admin"}, Hash: hash}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(store, time.Hour)
+	if _, err := a.Verify(ctx, plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range maxCacheEntries + 1 {
+		if _, err := a.Verify(ctx, fmt.Sprintf("%sunknown%d.x", prefix, i)); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("unknown key: err = %v, want ErrInvalidKey", err)
+		}
+	}
+	gets := store.gets
+	if _, err := a.Verify(ctx, plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if store.gets != gets {
+		t.Error("unknown ids flushed a valid key from the cache")
+	}
+	// A recent miss is answered from the cache too
+	if _, err := a.Verify(ctx, fmt.Sprintf("%sunknown%d.x", prefix, maxCacheEntries)); !errors.Is(err, ErrInvalidKey) || store.gets != gets {
+		t.Errorf("repeated unknown key: err = %v, %d lookups; want ErrInvalidKey from the cache", err, store.gets-gets)
+	}
+}
@@ -0,0 +1,124 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore implements Store on a DynamoDB table (PK: id).
+type DynamoStore struct {
+	db    *dynamodb.Client
+	table string
+}
+
+func NewDynamoStore(db *dynamodb.Client, table string) *DynamoStore {
+	return &DynamoStore{db: db, table: table}
+}
+
+func (s *DynamoStore) Create(ctx context.Context, k *Key) error {
+	item, err := attributevalue.MarshalMap(k)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.table,
+		Item:                item,
+		ConditionExpression: awsString("attribute_not_exists(id)"),
+	})
+	return err
+}
+
+func (s *DynamoStore) Get(ctx context.Context, id string) (*Key, error) {
+	res, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &s.table,
+		Key:       keyOf(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Item == nil {
+		return nil, ErrNotFound
+	}
+	var k Key
+	if err := attributevalue.UnmarshalMap(res.Item, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (s *DynamoStore) List(ctx context.Context) ([]Key, error) {
+	out := []Key{}
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{TableName: &s.table})
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []Key
+		if err := attributevalue.UnmarshalListOfMaps(res.Items, &page); err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+	}
+	return out, nil
+}
+
+func (s *DynamoStore) Rotate(ctx context.Context, id, hash, rotatedAt string) (*Key, error) {
+	res, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                &s.table,
+		Key:                      keyOf(id),
+		UpdateExpression:         awsString("SET #hash = :hash, rotated_at = :now"),
+		ConditionExpression:      awsString("attribute_exists(id) AND attribute_not_exists(revoked_at)"),
+		ExpressionAttributeNames: map[string]string{"#hash": "hash"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hash": &types.AttributeValueMemberS{Value: hash},
+			":now":  &types.AttributeValueMemberS{Value: rotatedAt},
+		},
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		return nil, conditionErr(err)
+	}
+	var k Key
+	if err := attributevalue.UnmarshalMap(res.Attributes, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (s *DynamoStore) Revoke(ctx context.Context, id, revokedAt string) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           &s.table,
+		Key:                 keyOf(id),
+		UpdateExpression:    awsString("SET revoked_at = if_not_exists(revoked_at, :now)"),
+		ConditionExpression: awsString("attribute_exists(id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberS{Value: revokedAt},
+		},
+	})
+	return conditionErr(err)
+}
+
+// conditionErr maps a failed attribute_exists(id) condition to ErrNotFound,
+// or to ErrRevoked when the old item is returned.
+func conditionErr(err error) error {
+	var ccf *types.ConditionalCheckFailedException
+	if !errors.As(err, &ccf) {
+		return err
+	}
+	if ccf.Item != nil {
+		return ErrRevoked
+	}
+	return ErrNotFound
+}
+
+func keyOf(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func awsString(s string) *string { return &s }
@@ -0,0 +1,78 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory, for tests and STORAGE=memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]Key
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]Key{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, k *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[k.ID]; ok {
+		return errors.New("api key already exists")
+	}
+	s.keys[k.ID] = *k
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &k, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *MemoryStore) Rotate(ctx context.Context, id, hash, rotatedAt string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if k.RevokedAt != "" {
+		return nil, ErrRevoked
+	}
+	k.Hash, k.RotatedAt = hash, rotatedAt
+	s.keys[id] = k
+	return &k, nil
+}
+
+func (s *MemoryStore) Revoke(ctx context.Context, id, revokedAt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if k.RevokedAt == "" {
+		k.RevokedAt = revokedAt
+		s.keys[id] = k
+	}
+	return nil
+}
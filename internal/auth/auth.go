@@ -0,0 +1,37 @@
+// Package auth verifies bearer tokens and carries the authenticated caller
+// through request contexts.
+package auth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keys under which the authentication middleware stores the caller in the Gin context.
+const (
+	SubjectKey  = "auth.subject"
+	ClaimsKey   = "auth.claims"
+	APIKeyIDKey = "auth.api_key_id" // set when the caller used an API key
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string
+	Roles   []string
+	Tenant  string // tenant the caller is bound to, if any
+	Claims  jwt.MapClaims
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the caller stored by NewContext, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok
+}
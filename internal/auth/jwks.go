@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JWKS refresh policy for key sets loaded from a URL: keys are refetched once
+// they are older than jwksMaxAge, or when a token names an unknown kid, but
+// never more often than jwksMinRefresh so bad tokens cannot hammer the issuer.
+const (
+	jwksMaxAge     = time.Hour
+	jwksMinRefresh = time.Minute
+	jwksMaxBytes   = 1 << 20
+)
+
+// ErrUnknownKey is returned when no key in the set matches a token's kid.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// keySet holds the public keys of a JWKS document, indexed by kid.
+type keySet struct {
+	url    string // empty for a key set loaded from a file
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	fetching  chan struct{} // closed when the running refresh ends; nil when none is running
+}
+
+func loadKeySetFile(path string) (*keySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return nil, fmt.Errorf("jwks %s: %w", path, err)
+	}
+	return &keySet{keys: keys}, nil
+}
+
+func loadKeySetURL(ctx context.Context, url string) (*keySet, error) {
+	ks := &keySet{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+	keys, err := ks.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ks.keys, ks.fetchedAt = keys, time.Now()
+	return ks, nil
+}
+
+// key returns the key for kid. An empty kid matches when the set holds a single key.
+//
+// Refreshes run in the background, outside the lock, so a slow issuer never
+// holds up verification with known keys: a stale set keeps serving while it
+// is refetched, and only tokens with an unknown kid wait for the refresh.
+func (ks *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	k, ok := ks.lookup(kid)
+	refreshed := ks.fetching
+	if refreshed == nil && ks.url != "" && (!ok || time.Since(ks.fetchedAt) > jwksMaxAge) && time.Since(ks.fetchedAt) > jwksMinRefresh {
+		refreshed = ks.startRefreshLocked()
+	}
+	ks.mu.Unlock()
+	if !ok && refreshed != nil {
+		select {
+		case <-refreshed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		ks.mu.Lock()
+		k, ok = ks.lookup(kid)
+		ks.mu.Unlock()
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return k, nil
+}
+
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, true
+		}
+	}
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// startRefreshLocked starts refetching the keys in the background and returns
+// a channel closed when it ends. Callers hold ks.mu and check that no refresh
+// is running.
+func (ks *keySet) startRefreshLocked() chan struct{} {
+	done := make(chan struct{})
+	ks.fetching, ks.fetchedAt = done, time.Now()
+	go func() {
+		// Not tied to the request that triggered it, which other requests may be waiting on.
+		keys, err := ks.fetch(context.Background())
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		// Keep serving the cached keys if the issuer is briefly unreachable.
+		if err == nil {
+			ks.keys = keys
+		}
+		ks.fetching = nil
+		close(done)
+	}()
+	return done
+}
+
+// fetch downloads and parses the key set.
+func (ks *keySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: %s", res.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(res.Body, jwksMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return nil, fmt.Errorf("jwks %s: %w", ks.url, err)
+	}
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the RSA and EC signing keys of a JWKS document; other keys are skipped.
+func parseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			pub crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaKey(k)
+		case "EC":
+			pub, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := b64Int(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := b64Int(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := b64Int(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := b64Int(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
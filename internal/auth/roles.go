@@ -0,0 +1,59 @@
+package auth
+
+import "strings"
+
+// Roles recognized in the roles claim. Callers without a recognized role are customers.
+const (
+	RoleAdmin    = "admin"    // read and modify every order
+	RoleSupport  = "support"  // read every order, modify none
+	RoleCustomer = "customer" // read and modify only the orders they own
+)
+
+// DefaultRolesClaim is the claim read for roles when Config.RolesClaim is empty.
+const DefaultRolesClaim = "roles"
+
+// HasRole reports whether p holds role.
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReadAll reports whether p may read orders owned by others.
+// A nil Principal (authentication disabled) has full access.
+func (p *Principal) CanReadAll() bool {
+	return p == nil || p.HasRole(RoleAdmin) || p.HasRole(RoleSupport)
+}
+
+// CanWriteAll reports whether p may modify orders owned by others.
+func (p *Principal) CanWriteAll() bool {
+	return p == nil || p.HasRole(RoleAdmin)
+}
+
+// CanWrite reports whether p may modify orders at all; support is read-only.
+func (p *Principal) CanWrite() bool {
+	return p.CanWriteAll() || !p.HasRole(RoleSupport)
+}
+
+// rolesFromClaim reads a roles claim given as a JSON array or a space-separated string.
+func rolesFromClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		var roles []string
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
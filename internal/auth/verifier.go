@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config selects how tokens are verified. At least one of JWKSFile, JWKSURL
+// or HS256Secret must be set; Issuer and Audience are checked when non-empty.
+type Config struct {
+	JWKSFile    string // RS256/ES256 public keys from a JWKS file
+	JWKSURL     string // RS256/ES256 public keys fetched and refreshed from a JWKS endpoint
+	HS256Secret []byte // shared secret, intended for local development
+	Issuer      string
+	Audience    string
+	RolesClaim  string // claim holding the caller's roles; defaults to DefaultRolesClaim
+	TenantClaim string // claim holding the caller's tenant; defaults to DefaultTenantClaim
+}
+
+// DefaultTenantClaim is the claim read for the tenant when Config.TenantClaim is empty.
+const DefaultTenantClaim = "tenant_id"
+
+// Enabled reports whether any verification key is configured.
+func (c Config) Enabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != "" || len(c.HS256Secret) > 0
+}
+
+// leeway tolerates clock skew between the issuer and this service.
+const leeway = 30 * time.Second
+
+// Verifier validates bearer tokens.
+type Verifier struct {
+	keys        *keySet
+	secret      []byte
+	parser      *jwt.Parser
+	rolesClaim  string
+	tenantClaim string
+}
+
+// NewVerifier loads the configured keys. A JWKS URL is fetched once up front
+// so misconfiguration fails at startup rather than on the first request.
+func NewVerifier(ctx context.Context, cfg Config) (*Verifier, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("auth: no verification keys configured")
+	}
+	v := &Verifier{secret: cfg.HS256Secret, rolesClaim: cfg.RolesClaim, tenantClaim: cfg.TenantClaim}
+	if v.rolesClaim == "" {
+		v.rolesClaim = DefaultRolesClaim
+	}
+	if v.tenantClaim == "" {
+		v.tenantClaim = DefaultTenantClaim
+	}
+	var err error
+	switch {
+	case cfg.JWKSFile != "" && cfg.JWKSURL != "":
+		return nil, errors.New("auth: set either a JWKS file or a JWKS URL, not both")
+	case cfg.JWKSFile != "":
+		v.keys, err = loadKeySetFile(cfg.JWKSFile)
+	case cfg.JWKSURL != "":
+		v.keys, err = loadKeySetURL(ctx, cfg.JWKSURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	var methods []string
+	if v.keys != nil {
+		methods = append(methods, "RS256", "ES256")
+	}
+	if len(v.secret) > 0 {
+		methods = append(methods, "HS256")
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify checks the token's signature and registered claims and returns its caller.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return v.secret, nil
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		// The key type must match the algorithm, or an RSA key could be
+		// offered to the ECDSA verifier and vice versa.
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			if _, ok := key.(*rsa.PublicKey); !ok {
+				return nil, ErrUnknownKey
+			}
+		case *jwt.SigningMethodECDSA:
+			if _, ok := key.(*ecdsa.PublicKey); !ok {
+				return nil, ErrUnknownKey
+			}
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, errors.New("token has no subject")
+	}
+	tenant, _ := claims[v.tenantClaim].(string)
+	return &Principal{Subject: sub, Roles: rolesFromClaim(claims[v.rolesClaim]), Tenant: tenant, Claims: claims}, nil
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com/"
+	testAudience = "orders-api"
+)
+
+var testSecret = []byte("test-secret-at-least-32-bytes-long!!")
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(kid string, k *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+}
+
+func ecJWK(kid string, k *ecdsa.PublicKey) map[string]string {
+	size := (k.Curve.Params().BitSize + 7) / 8
+	return map[string]string{"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256", "x": b64(k.X.FillBytes(make([]byte, size))), "y": b64(k.Y.FillBytes(make([]byte, size)))}
+}
+
+// jwksServer serves the given keys and lets tests rotate them.
+type jwksServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) add(k map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, k)
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"iss":       testIssuer,
+		"aud":       testAudience,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"roles":     []string{"admin"},
+		"tenant_id": "acme",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func with(claims jwt.MapClaims, key string, value any) jwt.MapClaims {
+	claims[key] = value
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+	v, err := NewVerifier(t.Context(), Config{JWKSURL: srv.URL, HS256Secret: testSecret, Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	none := func() string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()), true},
+		{"HS256", sign(t, jwt.SigningMethodHS256, "", testSecret, validClaims()), true},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "iss", "https://evil.example.com/")), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "aud", "other-api")), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "exp", nil)), false},
+		{"bad signature", sign(t, jwt.SigningMethodRS256, "rsa-1", otherRSA, validClaims()), false},
+		{"bad HS256 secret", sign(t, jwt.SigningMethodHS256, "", []byte("some-other-secret-of-enough-length"), validClaims()), false},
+		{"alg none", none(), false},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, validClaims()), false},
+		{"key type mismatch", sign(t, jwt.SigningMethodES256, "rsa-1", ecKey, validClaims()), false},
+		{"no subject", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(validClaims(), "sub", "")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(t.Context(), tt.token)
+			if !tt.ok {
+				if err == nil {
+					t.Fatalf("Verify accepted the token as %+v", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.Subject != "user-1" || p.Tenant != "acme" || len(p.Roles) != 1 || p.Roles[0] != "admin" {
+				t.Errorf("principal = %+v", p)
+			}
+		})
+	}
+}
+
+func TestVerifyRefreshesOnUnknownKid(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+	v, err := NewVerifier(t.Context(), Config{JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.add(rsaJWK("new", &newKey.PublicKey))
+	token := sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims())
+
+	// Within jwksMinRefresh of the last fetch the rotated key is not looked up
+	if _, err := v.Verify(t.Context(), token); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Verify = %v, want ErrUnknownKey", err)
+	}
+
+	v.keys.mu.Lock()
+	v.keys.fetchedAt = time.Now().Add(-2 * jwksMinRefresh)
+	v.keys.mu.Unlock()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Verify(t.Context(), token); err != nil {
+				t.Errorf("Verify after rotation: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := v.Verify(t.Context(), sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims())); err != nil {
+		t.Errorf("Verify with the old key: %v", err)
+	}
+}
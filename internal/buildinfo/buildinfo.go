@@ -0,0 +1,39 @@
+// Package buildinfo reports the version of the running binary.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X go-serverless-api-terraform/internal/buildinfo.Version=v1.2.3 -X go-serverless-api-terraform/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Commit defaults to the VCS revision Go stamps into binaries built from a checkout.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build info of the running binary.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+	if info.Commit == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" {
+					info.Commit = s.Value
+				}
+			}
+		}
+	}
+	return info
+}
@@ -1,33 +1,273 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"go-serverless-api-terraform/internal/ratelimit"
 )
 
 // Config holds environment-driven configuration
 // Keep fields aligned with previous main.go behavior
 // so no behavior change for users or deployment.
 type Config struct {
-	Port            string
-	AWSRegion       string
-	DynamoEndpoint  string // optional for local DynamoDB
-	OrdersTable     string
-	OrderItemsTable string
-	Env             string // e.g., "local" or "lambda"
+	Port             string
+	AWSRegion        string
+	DynamoEndpoint   string // optional for local DynamoDB
+	OrdersTable      string
+	OrderItemsTable  string
+	OrderEventsTable string // order history (audit events)
+	Env              string // e.g., "local" or "lambda"
+	LambdaHandler    string // in Lambda mode: "api" (default) or "streams"
+	Storage          string // "dynamo" (default) or "memory"
+	LogLevel         string // debug, info (default), warn or error
+
+	MetricsEnabled   bool   // Prometheus /metrics locally, EMF log lines in Lambda
+	MetricsNamespace string // CloudWatch namespace for EMF metrics
+
+	TraceExporter string // otlp, stdout or none (default)
+	ServiceName   string // service.name reported with traces
+
+	ReadinessTimeout  time.Duration // bound on the /readyz DescribeTable calls
+	ReadinessCacheTTL time.Duration // how long a /readyz result is reused
+
+	// Local HTTP server; RequestTimeout also applies in Lambda mode
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	RequestTimeout    time.Duration // context deadline for handlers and repository calls
+	ShutdownTimeout   time.Duration // how long in-flight requests may drain on SIGINT/SIGTERM
+
+	RateLimitBackend string                     // none (default), memory or dynamo
+	RateLimitsTable  string                     // DynamoDB table for rate limit counters (PK: key, TTL: expires_at)
+	RateLimits       map[string]ratelimit.Limit // per client IP (ip) and per route group: orders, items, admin
+
+	TrustedProxies []string // IPs or CIDRs whose X-Forwarded-For is believed locally; none by default
+
+	// CORS for browser clients; disabled when no origins are allowed
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// Unversioned aliases of the /v1 routes
+	LegacyRoutesEnabled      bool
+	LegacyRoutesDeprecatedAt time.Time // zero omits the Deprecation header
+	LegacyRoutesSunset       time.Time // zero omits the Sunset header
+
+	// Transactional outbox of domain events and its relay
+	OutboxEnabled      bool
+	OutboxTable        string        // DynamoDB table for unpublished events (PK: id)
+	OutboxPublisher    string        // none (default), memory, sns, sqs or eventbridge
+	OutboxTarget       string        // SNS topic ARN, SQS queue URL or EventBridge bus name
+	OutboxEventSource  string        // EventBridge source
+	OutboxPollInterval time.Duration // how often the in-process relay drains the outbox
+	OutboxMaxAttempts  int           // failed publishes after which an event is parked
+
+	IdempotencyTable string        // DynamoDB table for Idempotency-Key records (PK: key, TTL: expires_at)
+	IdempotencyTTL   time.Duration // how long responses are kept for replay
+	IdempotencyLease time.Duration // how long an in-progress request holds its key
+
+	// JWT authentication; disabled when no JWKS or secret is configured
+	JWKSFile        string
+	JWKSURL         string
+	JWTHS256Secret  string // only accepted with APP_ENV=local
+	JWTIssuer       string
+	JWTAudience     string
+	JWTRolesClaim   string   // claim listing admin/support/customer roles
+	AuthExemptPaths []string // route patterns served without a token
+
+	APIKeysEnabled bool          // accept X-API-Key and serve /admin/api-keys
+	APIKeysTable   string        // DynamoDB table for API keys (PK: id)
+	APIKeyCacheTTL time.Duration // how long key lookups are cached per instance
+
+	// Multi-tenancy; when enabled every request must resolve to a tenant
+	TenancyEnabled   bool
+	TenantHeader     string // request header naming the tenant
+	TenantBaseDomain string // resolve the tenant from subdomains of this domain
+	JWTTenantClaim   string // claim binding a token to a tenant
 }
 
 // Load loads env vars and .env (if present)
 func Load() (*Config, error) {
 	_ = godotenv.Load() // ignore error; only for local convenience
 	cfg := &Config{
-		Port:            getenvDefault("APP_PORT", "8080"),
-		AWSRegion:       getenvDefault("AWS_REGION", "us-east-1"),
-		DynamoEndpoint:  os.Getenv("DYNAMODB_ENDPOINT"),
-		OrdersTable:     getenvDefault("TABLE_ORDERS", "orders"),
-		OrderItemsTable: getenvDefault("TABLE_ORDER_ITEMS", "order_items"),
-		Env:             getenvDefault("APP_ENV", "local"),
+		Port:             getenvDefault("APP_PORT", "8080"),
+		AWSRegion:        getenvDefault("AWS_REGION", "us-east-1"),
+		DynamoEndpoint:   os.Getenv("DYNAMODB_ENDPOINT"),
+		OrdersTable:      getenvDefault("TABLE_ORDERS", "orders"),
+		OrderItemsTable:  getenvDefault("TABLE_ORDER_ITEMS", "order_items"),
+		OrderEventsTable: getenvDefault("TABLE_ORDER_EVENTS", "order_events"),
+		Env:              getenvDefault("APP_ENV", "local"),
+		LambdaHandler:    getenvDefault("LAMBDA_HANDLER", "api"),
+		Storage:          getenvDefault("STORAGE", "dynamo"),
+		LogLevel:         getenvDefault("LOG_LEVEL", "info"),
+
+		MetricsEnabled:   getenvDefault("METRICS_ENABLED", "false") == "true",
+		MetricsNamespace: getenvDefault("METRICS_NAMESPACE", "OrdersAPI"),
+
+		TraceExporter: getenvDefault("TRACING_EXPORTER", "none"),
+		ServiceName:   getenvDefault("OTEL_SERVICE_NAME", "orders-api"),
+
+		IdempotencyTable: getenvDefault("TABLE_IDEMPOTENCY", "idempotency_keys"),
+
+		JWKSFile:        os.Getenv("AUTH_JWKS_FILE"),
+		JWKSURL:         os.Getenv("AUTH_JWKS_URL"),
+		JWTHS256Secret:  os.Getenv("AUTH_HS256_SECRET"),
+		JWTIssuer:       os.Getenv("AUTH_ISSUER"),
+		JWTAudience:     os.Getenv("AUTH_AUDIENCE"),
+		JWTRolesClaim:   getenvDefault("AUTH_ROLES_CLAIM", "roles"),
+		APIKeysEnabled:  os.Getenv("API_KEYS_ENABLED") == "true",
+		APIKeysTable:    getenvDefault("TABLE_API_KEYS", "api_keys"),
+		AuthExemptPaths: getenvList("AUTH_EXEMPT_PATHS", []string{"/swagger/*any", "/healthz", "/readyz", "/metrics"}),
+
+		TenancyEnabled:   os.Getenv("TENANCY_ENABLED") == "true",
+		TenantHeader:     getenvDefault("TENANT_HEADER", "X-Tenant-ID"),
+		TenantBaseDomain: os.Getenv("TENANT_BASE_DOMAIN"),
+		JWTTenantClaim:   getenvDefault("AUTH_TENANT_CLAIM", "tenant_id"),
+
+		RateLimitBackend: getenvDefault("RATE_LIMIT_BACKEND", "none"),
+		RateLimitsTable:  getenvDefault("TABLE_RATE_LIMITS", "rate_limits"),
+		TrustedProxies:   getenvList("TRUSTED_PROXIES", nil),
+
+		CORSAllowedOrigins: getenvList("CORS_ALLOWED_ORIGINS", nil),
+		CORSAllowedMethods: getenvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE"}),
+		CORSAllowedHeaders: getenvList("CORS_ALLOWED_HEADERS", []string{
+			"Authorization", "Content-Type", "If-Match", "Idempotency-Key", "X-API-Key", "X-Request-ID", "X-Tenant-ID", "traceparent",
+		}),
+		CORSExposedHeaders: getenvList("CORS_EXPOSED_HEADERS", []string{
+			"ETag", "Location", "X-Request-ID", "Idempotent-Replayed", "Retry-After",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+		}),
+		CORSAllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+
+		LegacyRoutesEnabled: getenvDefault("LEGACY_ROUTES_ENABLED", "true") == "true",
+
+		OutboxEnabled:     os.Getenv("OUTBOX_ENABLED") == "true",
+		OutboxTable:       getenvDefault("TABLE_OUTBOX", "outbox"),
+		OutboxPublisher:   getenvDefault("OUTBOX_PUBLISHER", "none"),
+		OutboxTarget:      os.Getenv("OUTBOX_TARGET"),
+		OutboxEventSource: getenvDefault("OUTBOX_EVENT_SOURCE", "orders-api"),
+	}
+	var err error
+	if cfg.IdempotencyTTL, err = getenvDuration("IDEMPOTENCY_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.IdempotencyLease, err = getenvDuration("IDEMPOTENCY_LEASE", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.APIKeyCacheTTL, err = getenvDuration("API_KEY_CACHE_TTL", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.CORSMaxAge, err = getenvDuration("CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.LegacyRoutesDeprecatedAt, err = getenvTime("LEGACY_ROUTES_DEPRECATED_AT", "none"); err != nil {
+		return nil, err
+	}
+	if cfg.LegacyRoutesSunset, err = getenvTime("LEGACY_ROUTES_SUNSET", "none"); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxPollInterval, err = getenvDuration("OUTBOX_POLL_INTERVAL", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxMaxAttempts, err = getenvInt("OUTBOX_MAX_ATTEMPTS", 10); err != nil {
+		return nil, err
+	}
+	if cfg.ReadinessTimeout, err = getenvDuration("READINESS_TIMEOUT", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ReadinessCacheTTL, err = getenvDuration("READINESS_CACHE_TTL", 10*time.Second); err != nil {
+		return nil, err
+	}
+	for _, d := range []struct {
+		dst *time.Duration
+		key string
+		def time.Duration
+	}{
+		{&cfg.ReadTimeout, "HTTP_READ_TIMEOUT", 10 * time.Second},
+		{&cfg.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT", 5 * time.Second},
+		{&cfg.WriteTimeout, "HTTP_WRITE_TIMEOUT", 30 * time.Second},
+		{&cfg.IdleTimeout, "HTTP_IDLE_TIMEOUT", 120 * time.Second},
+		{&cfg.RequestTimeout, "REQUEST_TIMEOUT", 25 * time.Second},
+		{&cfg.ShutdownTimeout, "SHUTDOWN_TIMEOUT", 20 * time.Second},
+	} {
+		if *d.dst, err = getenvDuration(d.key, d.def); err != nil {
+			return nil, err
+		}
+	}
+	switch cfg.RateLimitBackend {
+	case "none", "memory", "dynamo":
+	default:
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BACKEND %q: want none, memory or dynamo", cfg.RateLimitBackend)
+	}
+	if cfg.RateLimitBackend == "dynamo" && cfg.Storage == "memory" {
+		return nil, fmt.Errorf("RATE_LIMIT_BACKEND=dynamo requires STORAGE=dynamo")
+	}
+	if cfg.RateLimits, err = getenvLimits("RATE_LIMITS", "ip=1200/1m,orders=300/1m,items=300/1m,admin=30/1m"); err != nil {
+		return nil, err
+	}
+	for _, p := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: want an IP address or CIDR", p)
+		}
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		return nil, fmt.Errorf("CORS_ALLOW_CREDENTIALS=true cannot be combined with CORS_ALLOWED_ORIGINS=*; list the trusted origins instead")
+	}
+	if cfg.RequestTimeout > 0 && cfg.WriteTimeout > 0 && cfg.RequestTimeout >= cfg.WriteTimeout {
+		return nil, fmt.Errorf("REQUEST_TIMEOUT (%s) must be shorter than HTTP_WRITE_TIMEOUT (%s) so timed-out requests still get a response", cfg.RequestTimeout, cfg.WriteTimeout)
+	}
+	if cfg.RequestTimeout > 0 && cfg.IdempotencyLease <= cfg.RequestTimeout {
+		return nil, fmt.Errorf("IDEMPOTENCY_LEASE (%s) must be longer than REQUEST_TIMEOUT (%s) so a running request keeps its key", cfg.IdempotencyLease, cfg.RequestTimeout)
+	}
+	if cfg.Storage != "dynamo" && cfg.Storage != "memory" {
+		return nil, fmt.Errorf("invalid STORAGE %q: want dynamo or memory", cfg.Storage)
+	}
+	switch cfg.LambdaHandler {
+	case "api":
+	case "streams":
+		if cfg.Storage != "dynamo" {
+			return nil, fmt.Errorf("LAMBDA_HANDLER=streams requires STORAGE=dynamo")
+		}
+	default:
+		return nil, fmt.Errorf("invalid LAMBDA_HANDLER %q: want api or streams", cfg.LambdaHandler)
+	}
+	switch cfg.TraceExporter {
+	case "otlp", "stdout", "none":
+	default:
+		return nil, fmt.Errorf("invalid TRACING_EXPORTER %q: want otlp, stdout or none", cfg.TraceExporter)
+	}
+	switch cfg.OutboxPublisher {
+	case "none", "memory":
+	case "sns", "sqs", "eventbridge":
+		if cfg.OutboxTarget == "" {
+			return nil, fmt.Errorf("OUTBOX_PUBLISHER=%s requires OUTBOX_TARGET", cfg.OutboxPublisher)
+		}
+	default:
+		return nil, fmt.Errorf("invalid OUTBOX_PUBLISHER %q: want none, memory, sns, sqs or eventbridge", cfg.OutboxPublisher)
+	}
+	if cfg.OutboxPublisher != "none" && !cfg.OutboxEnabled {
+		return nil, fmt.Errorf("OUTBOX_PUBLISHER requires OUTBOX_ENABLED=true")
+	}
+	if cfg.OutboxPollInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_POLL_INTERVAL must be positive")
+	}
+	if cfg.OutboxMaxAttempts <= 0 {
+		return nil, fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+	if cfg.JWTHS256Secret != "" && cfg.Env != "local" {
+		return nil, fmt.Errorf("AUTH_HS256_SECRET is only allowed with APP_ENV=local")
 	}
 	return cfg, nil
 }
@@ -39,3 +279,74 @@ func getenvDefault(k, d string) string {
 	}
 	return v
 }
+
+func getenvDuration(k string, d time.Duration) (time.Duration, error) {
+	v := os.Getenv(k)
+	if v == "" {
+		return d, nil
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", k, v, err)
+	}
+	return parsed, nil
+}
+
+// getenvInt parses an integer variable, returning d when it is unset.
+func getenvInt(k string, d int) (int, error) {
+	v := os.Getenv(k)
+	if v == "" {
+		return d, nil
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", k, v, err)
+	}
+	return parsed, nil
+}
+
+// getenvTime parses an RFC 3339 timestamp; "none" yields the zero time.
+func getenvTime(k, d string) (time.Time, error) {
+	v := getenvDefault(k, d)
+	if v == "none" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: want an RFC 3339 timestamp", k, v)
+	}
+	return t, nil
+}
+
+// getenvList splits a comma-separated variable. Unlike getenvDefault, a
+// variable that is set but empty yields an empty list.
+func getenvList(k string, d []string) []string {
+	v, ok := os.LookupEnv(k)
+	if !ok {
+		return d
+	}
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// getenvLimits parses a comma-separated list of group=<requests>/<window>.
+func getenvLimits(k, d string) (map[string]ratelimit.Limit, error) {
+	out := map[string]ratelimit.Limit{}
+	for _, s := range getenvList(k, strings.Split(d, ",")) {
+		group, limit, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry %q: want group=<requests>/<window>", k, s)
+		}
+		l, err := ratelimit.ParseLimit(limit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[strings.TrimSpace(group)] = l
+	}
+	return out, nil
+}
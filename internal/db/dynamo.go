@@ -11,8 +11,10 @@ import (
 	"go-serverless-api-terraform/internal/config"
 )
 
-// NewDynamoClient creates a DynamoDB client, optionally targeting a custom endpoint (e.g., DynamoDB Local)
-func NewDynamoClient(ctx context.Context, cfg *config.Config) (*dynamodb.Client, error) {
+// NewDynamoClient creates a DynamoDB client, optionally targeting a custom endpoint (e.g., DynamoDB Local).
+// opts, such as WithMetrics, are applied after the built-in call logging and tracing.
+func NewDynamoClient(ctx context.Context, cfg *config.Config, opts ...func(*dynamodb.Options)) (*dynamodb.Client, error) {
+	opts = append([]func(*dynamodb.Options){withLogging, withTracing}, opts...)
 	if cfg == nil {
 		return nil, errors.New("config is nil")
 	}
@@ -28,11 +30,11 @@ func NewDynamoClient(ctx context.Context, cfg *config.Config) (*dynamodb.Client,
 		if err != nil {
 			return nil, err
 		}
-		return dynamodb.NewFromConfig(awsCfg), nil
+		return dynamodb.NewFromConfig(awsCfg, opts...), nil
 	}
 	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.AWSRegion))
 	if err != nil {
 		return nil, err
 	}
-	return dynamodb.NewFromConfig(awsCfg), nil
+	return dynamodb.NewFromConfig(awsCfg, opts...), nil
 }
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+
+	"go-serverless-api-terraform/internal/logging"
+)
+
+// withLogging logs every DynamoDB call at debug level with the logger in the
+// call's context, so calls carry the request ID of the request making them.
+func withLogging(o *dynamodb.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("LogCall",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, md, err := next.HandleInitialize(ctx, in)
+				attrs := []any{
+					"operation", awsmiddleware.GetOperationName(ctx),
+					"duration_ms", time.Since(start).Milliseconds(),
+				}
+				if id, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+					attrs = append(attrs, "aws_request_id", id)
+				}
+				if err != nil {
+					attrs = append(attrs, "error", err.Error())
+				}
+				logging.FromContext(ctx).DebugContext(ctx, "dynamodb call", attrs...)
+				return out, md, err
+			}), middleware.After)
+	})
+}
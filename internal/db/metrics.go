@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+
+	"go-serverless-api-terraform/internal/metrics"
+)
+
+// WithMetrics records the latency, errors, throttles and consumed capacity of
+// every DynamoDB call made through the client with rec. It asks DynamoDB to
+// return consumed capacity on every operation that supports it.
+func WithMetrics(rec metrics.Recorder) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RecordMetrics",
+				func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					requestCapacity(in.Parameters)
+					table := tableOf(in.Parameters)
+					start := time.Now()
+					out, md, err := next.HandleInitialize(ctx, in)
+					rec.ObserveDynamo(ctx, metrics.DynamoCall{
+						Operation: awsmiddleware.GetOperationName(ctx),
+						Table:     table,
+						Duration:  time.Since(start),
+						Throttled: isThrottle(err),
+						Failed:    err != nil,
+						Capacity:  consumedCapacity(out.Result),
+					})
+					return out, md, err
+				}), middleware.After)
+		})
+	}
+}
+
+// requestCapacity sets ReturnConsumedCapacity on the input.
+func requestCapacity(params any) {
+	total := types.ReturnConsumedCapacityTotal
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.PutItemInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.UpdateItemInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.DeleteItemInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.QueryInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.ScanInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.TransactWriteItemsInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.TransactGetItemsInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.BatchWriteItemInput:
+		in.ReturnConsumedCapacity = total
+	case *dynamodb.BatchGetItemInput:
+		in.ReturnConsumedCapacity = total
+	}
+}
+
+// consumedCapacity returns the capacity units reported in out, by table.
+func consumedCapacity(out any) map[string]float64 {
+	var ccs []types.ConsumedCapacity
+	switch o := out.(type) {
+	case *dynamodb.GetItemOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.PutItemOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.UpdateItemOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.DeleteItemOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.QueryOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.ScanOutput:
+		ccs = single(o.ConsumedCapacity)
+	case *dynamodb.TransactWriteItemsOutput:
+		ccs = o.ConsumedCapacity
+	case *dynamodb.TransactGetItemsOutput:
+		ccs = o.ConsumedCapacity
+	case *dynamodb.BatchWriteItemOutput:
+		ccs = o.ConsumedCapacity
+	case *dynamodb.BatchGetItemOutput:
+		ccs = o.ConsumedCapacity
+	}
+	if len(ccs) == 0 {
+		return nil
+	}
+	m := make(map[string]float64, len(ccs))
+	for _, cc := range ccs {
+		if cc.CapacityUnits != nil {
+			m[deref(cc.TableName)] += *cc.CapacityUnits
+		}
+	}
+	return m
+}
+
+func isThrottle(err error) bool {
+	var api smithy.APIError
+	if !errors.As(err, &api) {
+		return false
+	}
+	switch api.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "RequestLimitExceeded", "ThrottlingException":
+		return true
+	}
+	return false
+}
+
+func single(cc *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if cc == nil {
+		return nil
+	}
+	return []types.ConsumedCapacity{*cc}
+}
@@ -0,0 +1,31 @@
+package db
+
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+// tableOf returns the table an operation input targets, or "" for
+// operations spanning tables.
+func tableOf(params any) string {
+	var name *string
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		name = in.TableName
+	case *dynamodb.PutItemInput:
+		name = in.TableName
+	case *dynamodb.UpdateItemInput:
+		name = in.TableName
+	case *dynamodb.DeleteItemInput:
+		name = in.TableName
+	case *dynamodb.QueryInput:
+		name = in.TableName
+	case *dynamodb.ScanInput:
+		name = in.TableName
+	}
+	return deref(name)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-serverless-api-terraform/internal/tracing"
+)
+
+// withTracing wraps every DynamoDB call in a client span, a child of the
+// span in the call's context (normally the request's server span). The span
+// covers SDK retries.
+func withTracing(o *dynamodb.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Trace",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				op := awsmiddleware.GetOperationName(ctx)
+				ctx, span := tracing.Tracer().Start(ctx, "DynamoDB."+op,
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(
+						semconv.RPCSystemKey.String("aws-api"),
+						semconv.RPCService("DynamoDB"),
+						semconv.RPCMethod(op),
+						semconv.DBSystemDynamoDB,
+					))
+				defer span.End()
+				if table := tableOf(in.Parameters); table != "" {
+					span.SetAttributes(semconv.AWSDynamoDBTableNames(table))
+				}
+
+				out, md, err := next.HandleInitialize(ctx, in)
+				if id, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+					span.SetAttributes(semconv.AWSRequestID(id))
+				}
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+				return out, md, err
+			}), middleware.After)
+	})
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoTable checks a DynamoDB table with DescribeTable. The table is ready
+// while ACTIVE or UPDATING; the latter still serves reads and writes.
+type DynamoTable struct {
+	DB    *dynamodb.Client
+	Table string
+}
+
+func (t DynamoTable) Name() string { return "dynamodb:" + t.Table }
+
+func (t DynamoTable) Check(ctx context.Context) (string, error) {
+	res, err := t.DB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &t.Table})
+	if err != nil {
+		return "", err
+	}
+	status := res.Table.TableStatus
+	switch status {
+	case types.TableStatusActive, types.TableStatusUpdating:
+		return string(status), nil
+	}
+	return string(status), fmt.Errorf("table %s is %s", t.Table, status)
+}
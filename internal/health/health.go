@@ -0,0 +1,95 @@
+// Package health checks the dependencies the service needs to serve requests.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status values reported by Check.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Checker checks one dependency. It returns the dependency's own status
+// (e.g. a DynamoDB table status) and an error if the dependency can't serve requests.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of one Checker.
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status    string            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt string            `json:"checked_at"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// Readiness runs checkers concurrently, each bounded by timeout, and caches
+// the report for ttl so frequent probes don't add load on dependencies.
+type Readiness struct {
+	checkers []Checker
+	timeout  time.Duration
+	ttl      time.Duration
+
+	mu     sync.Mutex
+	last   Report
+	expiry time.Time
+}
+
+func NewReadiness(timeout, ttl time.Duration, checkers ...Checker) *Readiness {
+	return &Readiness{checkers: checkers, timeout: timeout, ttl: ttl}
+}
+
+// Check returns the cached report, running the checks if it has expired.
+// Concurrent callers wait for one run rather than starting their own. The
+// checks are not canceled with ctx, so a probe that gives up early does not
+// leave a failed report cached for everyone else.
+func (r *Readiness) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().Before(r.expiry) {
+		return r.last
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	results := make([]Result, len(r.checkers))
+	var wg sync.WaitGroup
+	for i, c := range r.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := c.Check(ctx)
+			results[i] = Result{Status: status}
+			if err != nil {
+				results[i].Error = err.Error()
+				if results[i].Status == "" {
+					results[i].Status = StatusUnavailable
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: map[string]Result{}, CheckedAt: time.Now().UTC().Format(time.RFC3339)}
+	for i, c := range r.checkers {
+		report.Checks[c.Name()] = results[i]
+		if results[i].Error != "" {
+			report.Status = StatusUnavailable
+		}
+	}
+	r.last, r.expiry = report, time.Now().Add(r.ttl)
+	return report
+}
@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowChecker passes unless its context ends within delay.
+type slowChecker struct{ delay time.Duration }
+
+func (slowChecker) Name() string { return "slow" }
+
+func (s slowChecker) Check(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(s.delay):
+		return StatusOK, nil
+	}
+}
+
+func TestCheckIgnoresProbeCancellation(t *testing.T) {
+	r := NewReadiness(time.Second, time.Minute, slowChecker{delay: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := r.Check(ctx); !report.OK() {
+		t.Fatalf("report = %+v, want ok despite the canceled probe", report)
+	}
+
+	r = NewReadiness(time.Millisecond, time.Minute, slowChecker{delay: time.Second})
+	if report := r.Check(context.Background()); report.OK() || report.Checks["slow"].Status != StatusUnavailable {
+		t.Errorf("report = %+v, want the check to time out", report)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/repository"
+)
+
+// caller returns the authenticated principal, or nil when authentication is
+// disabled (see auth.Principal for how nil is treated).
+func caller(c *gin.Context) *auth.Principal {
+	p, _ := auth.FromContext(c.Request.Context())
+	return p
+}
+
+// canSee reports whether the caller may read o. Handlers answer ErrNotFound
+// for orders the caller cannot see, so their existence is not leaked.
+func canSee(c *gin.Context, o *models.Order) bool {
+	p := caller(c)
+	return p.CanReadAll() || o.OwnerID == p.Subject
+}
+
+// canModify is canSee for writes.
+func canModify(c *gin.Context, o *models.Order) bool {
+	p := caller(c)
+	return p.CanWriteAll() || o.OwnerID == p.Subject
+}
+
+// requireWrite rejects read-only callers with 403.
+func requireWrite(c *gin.Context) bool {
+	if !caller(c).CanWrite() {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "read-only role cannot modify orders")
+		return false
+	}
+	return true
+}
+
+// requireAdmin rejects callers without the admin role with 403.
+func requireAdmin(c *gin.Context) bool {
+	if p := caller(c); p != nil && !p.HasRole(auth.RoleAdmin) {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "admin role required")
+		return false
+	}
+	return true
+}
+
+// authorizeOrder checks the caller may read orderID (or modify it, with
+// write) for handlers that do not load the order themselves. Callers allowed
+// to access every order skip the lookup.
+func (h *Handler) authorizeOrder(c *gin.Context, orderID string, write bool) bool {
+	if write && !requireWrite(c) {
+		return false
+	}
+	p := caller(c)
+	if write && p.CanWriteAll() || !write && p.CanReadAll() {
+		return true
+	}
+	o, err := h.repo.GetOrder(c.Request.Context(), orderID)
+	if err == nil && o.OwnerID != p.Subject {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		respondError(c, err)
+		return false
+	}
+	return true
+}
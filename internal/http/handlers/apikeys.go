@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// APIKeys serves the admin endpoints that manage API keys. Keys are bound to
+// the request's tenant, and admins only see and manage their tenant's keys.
+type APIKeys struct {
+	store apikeys.Store
+	authn *apikeys.Authenticator // optional; its cache is cleared on rotate and revoke
+}
+
+func NewAPIKeys(store apikeys.Store, authn *apikeys.Authenticator) *APIKeys {
+	return &APIKeys{store: store, authn: authn}
+}
+
+type createAPIKeyReq struct {
+	Name string `json:"name" binding:"required"`
+	// OwnerID is the subject requests made with the key act as; defaults to the caller
+	OwnerID string `json:"owner_id"`
+	// Scopes are the roles granted to the key: admin, support or customer
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresAt is an optional RFC 3339 expiry
+	ExpiresAt string `json:"expires_at"`
+}
+
+// apiKeyResp is returned on create and rotate; Plaintext is never shown again.
+type apiKeyResp struct {
+	apikeys.Key
+	Plaintext string `json:"key"`
+}
+
+// Create godoc
+// @Summary Create API key
+// @Description Creates an API key. The plaintext key is returned once and cannot be retrieved later. Admin only.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body createAPIKeyReq true "Create API key payload"
+// @Success 201 {object} apiKeyResp
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/admin/api-keys [post]
+func (h *APIKeys) Create(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	var req createAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	for _, s := range req.Scopes {
+		if !apikeys.ValidScope(s) {
+			badRequest(c, "unknown scope "+strconv.Quote(s))
+			return
+		}
+	}
+	now := time.Now().UTC()
+	if req.ExpiresAt != "" {
+		exp, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !exp.After(now) {
+			badRequest(c, "expires_at must be a future RFC 3339 timestamp")
+			return
+		}
+		req.ExpiresAt = exp.UTC().Format(time.RFC3339)
+	}
+	if req.OwnerID == "" {
+		if p := caller(c); p != nil {
+			req.OwnerID = p.Subject
+		}
+	}
+	if req.OwnerID == "" {
+		badRequest(c, "owner_id is required")
+		return
+	}
+
+	id, plaintext, hash, err := apikeys.New()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	k := apikeys.Key{
+		ID:        id,
+		Name:      req.Name,
+		OwnerID:   req.OwnerID,
+		TenantID:  tenant.FromContext(c.Request.Context()),
+		Scopes:    req.Scopes,
+		Hash:      hash,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := h.store.Create(c.Request.Context(), &k); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, apiKeyResp{Key: k, Plaintext: plaintext})
+}
+
+// List godoc
+// @Summary List API keys
+// @Description Returns every API key, including revoked ones, without secrets. Admin only.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} apikeys.Key
+// @Failure 403 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/admin/api-keys [get]
+func (h *APIKeys) List(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	keys, err := h.store.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	t := tenant.FromContext(c.Request.Context())
+	out := keys[:0]
+	for _, k := range keys {
+		if k.TenantID == t {
+			out = append(out, k)
+		}
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// Rotate godoc
+// @Summary Rotate API key
+// @Description Replaces the key's secret; the old secret stops working. The new plaintext key is returned once. Admin only.
+// @Tags api-keys
+// @Produce json
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} apiKeyResp
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/admin/api-keys/{keyId}/rotate [post]
+func (h *APIKeys) Rotate(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id := c.Param("keyId")
+	if err := h.checkTenant(c, id); err != nil {
+		h.respondKeyError(c, err)
+		return
+	}
+	plaintext, hash, err := apikeys.NewSecret(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	k, err := h.store.Rotate(c.Request.Context(), id, hash, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		h.respondKeyError(c, err)
+		return
+	}
+	h.forget(id)
+	c.JSON(http.StatusOK, apiKeyResp{Key: *k, Plaintext: plaintext})
+}
+
+// Revoke godoc
+// @Summary Revoke API key
+// @Description Permanently disables an API key. Admin only.
+// @Tags api-keys
+// @Param keyId path string true "API key ID"
+// @Success 204 {string} string
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/admin/api-keys/{keyId} [delete]
+func (h *APIKeys) Revoke(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id := c.Param("keyId")
+	if err := h.checkTenant(c, id); err != nil {
+		h.respondKeyError(c, err)
+		return
+	}
+	if err := h.store.Revoke(c.Request.Context(), id, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		h.respondKeyError(c, err)
+		return
+	}
+	h.forget(id)
+	c.Status(http.StatusNoContent)
+}
+
+// checkTenant returns apikeys.ErrNotFound unless key id belongs to the request's tenant.
+func (h *APIKeys) checkTenant(c *gin.Context, id string) error {
+	k, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+	if k.TenantID != tenant.FromContext(c.Request.Context()) {
+		return apikeys.ErrNotFound
+	}
+	return nil
+}
+
+func (h *APIKeys) forget(id string) {
+	if h.authn != nil {
+		h.authn.Forget(id)
+	}
+}
+
+func (h *APIKeys) respondKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apikeys.ErrNotFound):
+		notFound(c, "api key not found")
+	case errors.Is(err, apikeys.ErrRevoked):
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, "api key is revoked")
+	default:
+		respondError(c, err)
+	}
+}
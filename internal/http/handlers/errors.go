@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/repository"
+)
+
+// respondError maps a repository error to an RFC 7807 response.
+// Unexpected errors are attached to the gin context for logging and
+// never echoed to the client.
+func respondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+
+	var partial *repository.PartialDeleteError
+	switch {
+	case errors.As(err, &partial):
+		problem.Abort(c, problem.New(http.StatusInternalServerError, problem.CodePartialDelete,
+			"order partially deleted; retry the request to finish").
+			With("deleted_items", partial.DeletedItems).
+			With("remaining_items", partial.RemainingItems))
+	case errors.Is(err, repository.ErrInvalidCursor):
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid cursor")
+	case errors.Is(err, repository.ErrSortNeedsIndex):
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "resource not found")
+	case errors.Is(err, repository.ErrAlreadyExists):
+		problem.Write(c, http.StatusConflict, problem.CodeAlreadyExists, "resource already exists")
+	case errors.Is(err, repository.ErrVersionMismatch):
+		problem.Write(c, http.StatusPreconditionFailed, problem.CodeVersionMismatch,
+			"resource was modified; fetch it again and retry with the new ETag")
+	case errors.Is(err, models.ErrAmountOutOfRange):
+		problem.Write(c, http.StatusUnprocessableEntity, problem.CodeAmountOutOfRange, "line or order total exceeds the maximum amount")
+	case errors.Is(err, repository.ErrInvalidTransition):
+		problem.Write(c, http.StatusConflict, problem.CodeInvalidTransition, "status change not allowed from the current status")
+	case errors.Is(err, repository.ErrConflict):
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, "request conflicts with the current state of the resource")
+	case errors.Is(err, repository.ErrThrottled):
+		c.Header("Retry-After", "1")
+		problem.Write(c, http.StatusTooManyRequests, problem.CodeThrottled, "request rate too high; retry later")
+	case errors.Is(err, repository.ErrUnavailable):
+		c.Header("Retry-After", "5")
+		problem.Write(c, http.StatusServiceUnavailable, problem.CodeUnavailable, "storage temporarily unavailable")
+	case errors.Is(err, context.DeadlineExceeded):
+		problem.Write(c, http.StatusGatewayTimeout, problem.CodeTimeout, "request did not complete in time; retry it")
+	default:
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "internal error")
+	}
+}
+
+// badRequest writes a 400 problem with a client-facing detail message.
+func badRequest(c *gin.Context, detail string) {
+	problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, detail)
+}
+
+// notFound writes a 404 problem with a client-facing detail message.
+func notFound(c *gin.Context, detail string) {
+	problem.Write(c, http.StatusNotFound, problem.CodeNotFound, detail)
+}
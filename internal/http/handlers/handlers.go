@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"go-serverless-api-terraform/internal/http/problem"
 	"go-serverless-api-terraform/internal/models"
 	"go-serverless-api-terraform/internal/repository"
 )
@@ -25,6 +30,10 @@ func New(repo repository.Repository) *Handler {
 type createOrderReq struct {
 	CustomerName string `json:"customer_name" binding:"required"`
 	Status       string `json:"status"`
+	// Currency is the ISO 4217 code all item prices must use; defaults to USD
+	Currency string `json:"currency"`
+	// OwnerID defaults to the caller; only admins may create orders for someone else
+	OwnerID string `json:"owner_id"`
 }
 
 type updateOrderReq struct {
@@ -32,34 +41,73 @@ type updateOrderReq struct {
 	Status       *string `json:"status"`
 }
 
+type transitionReq struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// Prices are {"amount": "99.99", "currency": "USD"}; a bare number is read
+// as an amount in the order's currency.
 type createItemReq struct {
-	ProductName string  `json:"product_name" binding:"required"`
-	Quantity    int     `json:"quantity" binding:"required"`
-	Price       float64 `json:"price" binding:"required"`
+	ProductName string          `json:"product_name" binding:"required"`
+	Quantity    int             `json:"quantity" binding:"required"`
+	Price       json.RawMessage `json:"price" binding:"required" swaggertype:"object"`
 }
 
 type updateItemReq struct {
-	ProductName *string  `json:"product_name"`
-	Quantity    *int     `json:"quantity"`
-	Price       *float64 `json:"price"`
+	ProductName *string         `json:"product_name"`
+	Quantity    *int            `json:"quantity"`
+	Price       json.RawMessage `json:"price" swaggertype:"object"`
+}
+
+// pageResp wraps a page of results; NextCursor is omitted on the last page.
+type pageResp[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
+// Pagination defaults for list endpoints
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
 // Orders
 // ListOrders godoc
 // @Summary List orders
-// @Description Returns all orders
+// @Description Returns a page of orders; pass next_cursor back as cursor (with the same filters) to fetch the next page.
+// @Description Filtering by owner_id, status or customer_name uses an index and allows sorting by created_at; other filters may return short pages.
 // @Tags orders
 // @Produce json
-// @Success 200 {array} models.Order
-// @Failure 500 {object} map[string]string
-// @Router /orders [get]
+// @Param status query string false "Only orders in this status"
+// @Param customer_name query string false "Only orders for this customer"
+// @Param owner_id query string false "Only orders owned by this subject (admin and support; customers always see only their own)"
+// @Param created_from query string false "Only orders created at or after this RFC 3339 time"
+// @Param created_to query string false "Only orders created at or before this RFC 3339 time"
+// @Param sort query string false "created_at or -created_at; requires owner_id, status or customer_name" Enums(created_at, -created_at)
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Opaque cursor from a previous response"
+// @Success 200 {object} pageResp[models.Order]
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/orders [get]
 func (h *Handler) ListOrders(c *gin.Context) {
-	orders, err := h.repo.ListOrders(c.Request.Context())
+	pr, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+	f, ok := orderFilter(c)
+	if !ok {
+		return
+	}
+	if p := caller(c); !p.CanReadAll() {
+		f.OwnerID = p.Subject
+	}
+	page, err := h.repo.QueryOrders(c.Request.Context(), f, pr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, pageResp[models.Order]{Items: page.Items, NextCursor: page.NextCursor})
 }
 
 // CreateOrder godoc
@@ -68,134 +116,288 @@ func (h *Handler) ListOrders(c *gin.Context) {
 // @Tags orders
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Replays the stored response for retries of the same request"
 // @Param order body createOrderReq true "Create order payload"
 // @Success 201 {object} models.Order
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders [post]
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders [post]
 func (h *Handler) CreateOrder(c *gin.Context) {
+	if !requireWrite(c) {
+		return
+	}
 	var req createOrderReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
+		return
+	}
+	owner := req.OwnerID
+	if p := caller(c); owner == "" && p != nil {
+		owner = p.Subject
+	} else if owner != "" && !p.CanWriteAll() && owner != p.Subject {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "only admins may create orders for another owner")
+		return
+	}
+	if req.Status != "" && req.Status != models.StatusNew {
+		badRequest(c, "new orders must start in status "+models.StatusNew)
+		return
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if !models.ValidCurrency(currency) {
+		badRequest(c, "unsupported currency "+strconv.Quote(currency))
 		return
 	}
 	now := time.Now().UTC().Format(time.RFC3339)
 	order := &models.Order{
 		ID:           uuid.NewString(),
 		CustomerName: req.CustomerName,
-		Status:       defaultIfEmpty(req.Status, "new"),
+		OwnerID:      owner,
+		Status:       models.StatusNew,
+		Subtotal:     models.Money{Currency: currency},
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 	if err := h.repo.CreateOrder(c.Request.Context(), order); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(order.Version))
 	c.JSON(http.StatusCreated, order)
 }
 
 // GetOrder godoc
 // @Summary Get order
-// @Description Returns an order by ID
+// @Description Returns an order by ID, including item_count, subtotal and total; the ETag header carries its version
+// @Description Item creates, updates and deletes also bump the version, since they change the order's totals.
 // @Tags orders
 // @Produce json
 // @Param orderId path string true "Order ID"
 // @Success 200 {object} models.Order
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId} [get]
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/orders/{orderId} [get]
 func (h *Handler) GetOrder(c *gin.Context) {
 	id := c.Param("orderId")
 	order, err := h.repo.GetOrder(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if err == nil && !canSee(c, order) {
+		err = repository.ErrNotFound
 	}
-	if order == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(order.Version))
 	c.JSON(http.StatusOK, order)
 }
 
 // UpdateOrder godoc
 // @Summary Update order
-// @Description Updates an existing order by ID
+// @Description Updates an existing order by ID. Send If-Match with the ETag from a previous read to avoid overwriting concurrent changes. A status change must be a legal lifecycle transition.
+// @Description Item changes since that read also make the ETag stale (412), as they update the order's totals.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param orderId path string true "Order ID"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Param order body updateOrderReq true "Update order payload"
 // @Success 200 {object} models.Order
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId} [put]
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId} [put]
 func (h *Handler) UpdateOrder(c *gin.Context) {
+	if !requireWrite(c) {
+		return
+	}
 	id := c.Param("orderId")
 	existing, err := h.repo.GetOrder(c.Request.Context(), id)
+	if err == nil && !canModify(c, existing) {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	if existing == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	if !ifMatch(c, existing.Version) {
+		respondError(c, repository.ErrVersionMismatch)
 		return
 	}
 	var req updateOrderReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 	if req.CustomerName != nil {
 		existing.CustomerName = *req.CustomerName
 	}
-	if req.Status != nil {
+	if req.Status != nil && *req.Status != existing.Status {
+		if !models.ValidStatus(*req.Status) {
+			badRequest(c, "unknown status "+strconv.Quote(*req.Status))
+			return
+		}
+		if !models.CanTransition(existing.Status, *req.Status) {
+			respondError(c, repository.ErrInvalidTransition)
+			return
+		}
 		existing.Status = *req.Status
 	}
 	existing.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	if err := h.repo.UpdateOrder(c.Request.Context(), existing); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(existing.Version))
 	c.JSON(http.StatusOK, existing)
 }
 
+// TransitionOrder godoc
+// @Summary Change order status
+// @Description Moves an order to a new status. Allowed: new -> confirmed|cancelled, confirmed -> paid|cancelled, paid -> shipped|refunded, shipped -> delivered, delivered -> refunded.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Param Idempotency-Key header string false "Replays the stored response for retries of the same request"
+// @Param transition body transitionReq true "Target status"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId}/transitions [post]
+func (h *Handler) TransitionOrder(c *gin.Context) {
+	if !requireWrite(c) {
+		return
+	}
+	id := c.Param("orderId")
+	var req transitionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if !models.ValidStatus(req.Status) {
+		badRequest(c, "unknown status "+strconv.Quote(req.Status))
+		return
+	}
+	existing, err := h.repo.GetOrder(c.Request.Context(), id)
+	if err == nil && !canModify(c, existing) {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ifMatch(c, existing.Version) {
+		respondError(c, repository.ErrVersionMismatch)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	order, err := h.repo.TransitionOrder(c.Request.Context(), id, existing.Status, req.Status, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidTransition) {
+			problem.Abort(c, problem.New(http.StatusConflict, problem.CodeInvalidTransition,
+				"cannot move order from "+existing.Status+" to "+req.Status).
+				With("allowed", models.NextStatuses(existing.Status)))
+			return
+		}
+		respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag(order.Version))
+	c.JSON(http.StatusOK, order)
+}
+
 // DeleteOrder godoc
 // @Summary Delete order
-// @Description Deletes an order by ID
+// @Description Deletes an order and all of its items. If only part of a large order could be deleted, the order is kept and the request can be retried.
+// @Description Only the order's deletion is recorded in its history and events, not the removal of each item.
 // @Tags orders
 // @Param orderId path string true "Order ID"
 // @Success 204 {string} string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId} [delete]
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId} [delete]
 func (h *Handler) DeleteOrder(c *gin.Context) {
 	id := c.Param("orderId")
+	if !h.authorizeOrder(c, id, true) {
+		return
+	}
 	if err := h.repo.DeleteOrder(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// GetOrderHistory godoc
+// @Summary Get order history
+// @Description Returns a page of the order's audit events, oldest first: who created, updated or deleted the order or one of its items, when, and the fields that changed. Admins and support can read the history of deleted orders.
+// @Tags orders
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Opaque cursor from a previous response"
+// @Success 200 {object} pageResp[models.AuditEvent]
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/orders/{orderId}/history [get]
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	orderID := c.Param("orderId")
+	if !h.authorizeOrder(c, orderID, false) {
+		return
+	}
+	pr, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+	page, err := h.repo.ListOrderHistory(c.Request.Context(), orderID, pr)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pageResp[models.AuditEvent]{Items: page.Items, NextCursor: page.NextCursor})
+}
+
 // Items
 // ListItems godoc
 // @Summary List items of an order
-// @Description Returns all items for a given order
+// @Description Returns a page of items for a given order; pass next_cursor back as cursor to fetch the next page
 // @Tags items
 // @Produce json
 // @Param orderId path string true "Order ID"
-// @Success 200 {array} models.OrderItem
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId}/items [get]
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Opaque cursor from a previous response"
+// @Success 200 {object} pageResp[models.OrderItem]
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/orders/{orderId}/items [get]
 func (h *Handler) ListItems(c *gin.Context) {
 	orderID := c.Param("orderId")
-	items, err := h.repo.ListOrderItems(c.Request.Context(), orderID)
+	if !h.authorizeOrder(c, orderID, false) {
+		return
+	}
+	pr, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+	page, err := h.repo.ListOrderItemsPage(c.Request.Context(), orderID, pr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, items)
+	c.JSON(http.StatusOK, pageResp[models.OrderItem]{Items: page.Items, NextCursor: page.NextCursor})
 }
 
 // CreateItem godoc
@@ -205,34 +407,44 @@ func (h *Handler) ListItems(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param orderId path string true "Order ID"
+// @Param Idempotency-Key header string false "Replays the stored response for retries of the same request"
 // @Param item body createItemReq true "Create item payload"
 // @Success 201 {object} models.OrderItem
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId}/items [post]
+// @Failure 400 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId}/items [post]
 func (h *Handler) CreateItem(c *gin.Context) {
+	if !requireWrite(c) {
+		return
+	}
 	orderID := c.Param("orderId")
 	// Validate order exists
-	ord, err := h.repo.GetOrder(c.Request.Context(), orderID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	order, err := h.repo.GetOrder(c.Request.Context(), orderID)
+	if err == nil && !canModify(c, order) {
+		err = repository.ErrNotFound
 	}
-	if ord == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order does not exist"})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			badRequest(c, "order does not exist")
+			return
+		}
+		respondError(c, err)
 		return
 	}
 	var req createItemReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
-	if req.Quantity < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be >= 1"})
+	if !validQuantity(req.Quantity) {
+		badRequest(c, quantityMsg)
 		return
 	}
-	if req.Price < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be >= 0"})
+	price, msg := parsePrice(req.Price, order.Currency())
+	if msg != "" {
+		badRequest(c, msg)
 		return
 	}
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -241,96 +453,107 @@ func (h *Handler) CreateItem(c *gin.Context) {
 		ID:          uuid.NewString(),
 		ProductName: req.ProductName,
 		Quantity:    req.Quantity,
-		Price:       req.Price,
+		Price:       price,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 	if err := h.repo.CreateOrderItem(c.Request.Context(), it); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(it.Version))
 	c.JSON(http.StatusCreated, it)
 }
 
 // GetItem godoc
 // @Summary Get item
-// @Description Returns an item by ID for a given order
+// @Description Returns an item by ID for a given order; the ETag header carries its version
 // @Tags items
 // @Produce json
 // @Param orderId path string true "Order ID"
 // @Param itemId path string true "Item ID"
 // @Success 200 {object} models.OrderItem
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId}/items/{itemId} [get]
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /v1/orders/{orderId}/items/{itemId} [get]
 func (h *Handler) GetItem(c *gin.Context) {
 	orderID := c.Param("orderId")
 	id := c.Param("itemId")
-	it, err := h.repo.GetOrderItem(c.Request.Context(), orderID, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !h.authorizeOrder(c, orderID, false) {
 		return
 	}
-	if it == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+	it, err := h.repo.GetOrderItem(c.Request.Context(), orderID, id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(it.Version))
 	c.JSON(http.StatusOK, it)
 }
 
 // UpdateItem godoc
 // @Summary Update item
-// @Description Updates an item by ID for a given order
+// @Description Updates an item by ID for a given order. Send If-Match with the ETag from a previous read to avoid overwriting concurrent changes.
 // @Tags items
 // @Accept json
 // @Produce json
 // @Param orderId path string true "Order ID"
 // @Param itemId path string true "Item ID"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Param item body updateItemReq true "Update item payload"
 // @Success 200 {object} models.OrderItem
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId}/items/{itemId} [put]
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId}/items/{itemId} [put]
 func (h *Handler) UpdateItem(c *gin.Context) {
 	orderID := c.Param("orderId")
 	id := c.Param("itemId")
+	if !h.authorizeOrder(c, orderID, true) {
+		return
+	}
 	existing, err := h.repo.GetOrderItem(c.Request.Context(), orderID, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	if existing == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+	if !ifMatch(c, existing.Version) {
+		respondError(c, repository.ErrVersionMismatch)
 		return
 	}
 	var req updateItemReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 	if req.ProductName != nil {
 		existing.ProductName = *req.ProductName
 	}
 	if req.Quantity != nil {
-		if *req.Quantity < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be >= 1"})
+		if !validQuantity(*req.Quantity) {
+			badRequest(c, quantityMsg)
 			return
 		}
 		existing.Quantity = *req.Quantity
 	}
-	if req.Price != nil {
-		if *req.Price < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "price must be >= 0"})
+	if len(req.Price) > 0 && string(req.Price) != "null" {
+		// the stored price is always in the order's currency
+		price, msg := parsePrice(req.Price, existing.Price.Currency)
+		if msg != "" {
+			badRequest(c, msg)
 			return
 		}
-		existing.Price = *req.Price
+		existing.Price = price
 	}
 	existing.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	if err := h.repo.UpdateOrderItem(c.Request.Context(), existing); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
+	c.Header("ETag", etag(existing.Version))
 	c.JSON(http.StatusOK, existing)
 }
 
@@ -341,21 +564,119 @@ func (h *Handler) UpdateItem(c *gin.Context) {
 // @Param orderId path string true "Order ID"
 // @Param itemId path string true "Item ID"
 // @Success 204 {string} string
-// @Failure 500 {object} map[string]string
-// @Router /orders/{orderId}/items/{itemId} [delete]
+// @Failure 500 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Router /v1/orders/{orderId}/items/{itemId} [delete]
 func (h *Handler) DeleteItem(c *gin.Context) {
 	orderID := c.Param("orderId")
 	id := c.Param("itemId")
+	if !h.authorizeOrder(c, orderID, true) {
+		return
+	}
 	if err := h.repo.DeleteOrderItem(c.Request.Context(), orderID, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
-func defaultIfEmpty(s, d string) string {
-	if s == "" {
-		return d
+// pageRequest reads limit/cursor query params, writing a 400 and returning false when invalid.
+func pageRequest(c *gin.Context) (repository.PageRequest, bool) {
+	pr := repository.PageRequest{Limit: defaultPageLimit, Cursor: c.Query("cursor")}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			badRequest(c, "limit must be between 1 and "+strconv.Itoa(maxPageLimit))
+			return pr, false
+		}
+		pr.Limit = int32(n)
+	}
+	return pr, true
+}
+
+// orderFilter parses the ListOrders filter and sort query parameters.
+func orderFilter(c *gin.Context) (repository.OrderFilter, bool) {
+	f := repository.OrderFilter{
+		OwnerID:      c.Query("owner_id"),
+		Status:       c.Query("status"),
+		CustomerName: c.Query("customer_name"),
+	}
+	if f.Status != "" && !models.ValidStatus(f.Status) {
+		badRequest(c, "unknown status "+strconv.Quote(f.Status))
+		return f, false
+	}
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{{"created_from", &f.CreatedFrom}, {"created_to", &f.CreatedTo}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			badRequest(c, p.name+" must be an RFC 3339 timestamp")
+			return f, false
+		}
+		// Stored timestamps are UTC RFC 3339, which compare correctly as strings
+		*p.dst = t.UTC().Format(time.RFC3339)
+	}
+	if f.CreatedFrom != "" && f.CreatedTo != "" && f.CreatedFrom > f.CreatedTo {
+		badRequest(c, "created_from must not be after created_to")
+		return f, false
+	}
+	switch c.Query("sort") {
+	case "":
+	case "created_at":
+		f.Sort = repository.SortCreatedAsc
+	case "-created_at":
+		f.Sort = repository.SortCreatedDesc
+	default:
+		badRequest(c, "sort must be created_at or -created_at")
+		return f, false
+	}
+	return f, true
+}
+
+var quantityMsg = "quantity must be between 1 and " + strconv.Itoa(models.MaxQuantity)
+
+func validQuantity(n int) bool { return n >= 1 && n <= models.MaxQuantity }
+
+// parsePrice decodes a price in the order's currency, returning a
+// client-facing message if it is malformed or invalid for the order.
+func parsePrice(raw json.RawMessage, currency string) (models.Money, string) {
+	p, err := models.DecodeMoneyJSON(raw, currency)
+	if err != nil {
+		return models.Money{}, "invalid price: " + err.Error()
+	}
+	if p.Currency != currency {
+		return models.Money{}, "price currency must match the order currency " + currency
+	}
+	if p.Amount < 0 {
+		return models.Money{}, "price must be >= 0"
+	}
+	return p, ""
+}
+
+// etag renders a record version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch reports whether the request's If-Match header (if any) matches the current version.
+// A missing header or "*" always matches. If-Match uses the strong comparison
+// (RFC 9110 §13.1.1), so a weak tag never matches.
+func ifMatch(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
 	}
-	return s
+	return false
 }
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/repository"
+)
+
+// newTestRouter serves the order and item routes on a memory repository,
+// with authentication disabled.
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := New(repository.NewMemoryRepository(nil))
+	r := gin.New()
+	r.GET("/orders", h.ListOrders)
+	r.POST("/orders", h.CreateOrder)
+	r.GET("/orders/:orderId", h.GetOrder)
+	r.PUT("/orders/:orderId", h.UpdateOrder)
+	r.DELETE("/orders/:orderId", h.DeleteOrder)
+	r.GET("/orders/:orderId/items", h.ListItems)
+	r.POST("/orders/:orderId/items", h.CreateItem)
+	r.GET("/orders/:orderId/items/:itemId", h.GetItem)
+	return r
+}
+
+func do(t *testing.T, r http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func createOrder(t *testing.T, r http.Handler, customer string) models.Order {
+	t.Helper()
+	w := do(t, r, http.MethodPost, "/orders", `{"customer_name":"`+customer+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create order: status %d: %s", w.Code, w.Body.String())
+	}
+	return decode[models.Order](t, w)
+}
+
+func TestCreateAndGetOrder(t *testing.T) {
+	r := newTestRouter()
+	w := do(t, r, http.MethodPost, "/orders", `{"customer_name":"alice","currency":"EUR"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("ETag = %s, want \"1\"", got)
+	}
+	created := decode[models.Order](t, w)
+	if created.ID == "" || created.Status != models.StatusNew || created.Subtotal.Currency != "EUR" {
+		t.Errorf("created order = %+v", created)
+	}
+
+	w = do(t, r, http.MethodGet, "/orders/"+created.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: status = %d, want 200", w.Code)
+	}
+	if got := decode[models.Order](t, w); got.ID != created.ID || got.CustomerName != "alice" {
+		t.Errorf("get = %+v, want %+v", got, created)
+	}
+
+	if w := do(t, r, http.MethodGet, "/orders/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("get missing: status = %d, want 404", w.Code)
+	}
+	if w := do(t, r, http.MethodPost, "/orders", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("create without customer_name: status = %d, want 400", w.Code)
+	}
+}
+
+func TestListOrdersPagination(t *testing.T) {
+	r := newTestRouter()
+	want := map[string]bool{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		want[createOrder(t, r, name).ID] = true
+	}
+
+	seen := map[string]bool{}
+	cursor, pages := "", 0
+	for {
+		path := "/orders?limit=2"
+		if cursor != "" {
+			path += "&cursor=" + cursor
+		}
+		w := do(t, r, http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: status = %d: %s", w.Code, w.Body.String())
+		}
+		page := decode[pageResp[models.Order]](t, w)
+		pages++
+		if len(page.Items) > 2 {
+			t.Fatalf("page %d has %d items, want at most 2", pages, len(page.Items))
+		}
+		for _, o := range page.Items {
+			if seen[o.ID] {
+				t.Errorf("order %s returned twice", o.ID)
+			}
+			seen[o.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if pages != 3 || len(seen) != len(want) {
+		t.Errorf("got %d orders in %d pages, want %d in 3", len(seen), pages, len(want))
+	}
+
+	for _, path := range []string{"/orders?limit=0", "/orders?limit=101", "/orders?cursor=not-a-cursor"} {
+		if w := do(t, r, http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestUpdateOrderIfMatch(t *testing.T) {
+	r := newTestRouter()
+	o := createOrder(t, r, "alice")
+	path := "/orders/" + o.ID
+
+	w := do(t, r, http.MethodPut, path, `{"customer_name":"bob"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag = %s, want \"2\"", got)
+	}
+	if got := decode[models.Order](t, w); got.CustomerName != "bob" || got.Version != 2 {
+		t.Errorf("updated order = %+v", got)
+	}
+
+	tests := []struct {
+		name    string
+		ifMatch func(current string) string
+		want    int
+	}{
+		{"stale version", func(string) string { return `"1"` }, http.StatusPreconditionFailed},
+		{"weak current version", func(cur string) string { return "W/" + cur }, http.StatusPreconditionFailed},
+		{"current in list", func(cur string) string { return `"7", ` + cur }, http.StatusOK},
+		{"wildcard", func(string) string { return "*" }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := do(t, r, http.MethodGet, path, "").Header().Get("ETag")
+			ifMatch := tt.ifMatch(cur)
+			w := do(t, r, http.MethodPut, path, `{"customer_name":"carol"}`, "If-Match", ifMatch)
+			if w.Code != tt.want {
+				t.Errorf("If-Match %s: status = %d, want %d: %s", ifMatch, w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	w = do(t, r, http.MethodPut, path, `{"status":"shipped"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("illegal transition: status = %d, want 409", w.Code)
+	}
+}
+
+func TestDeleteOrder(t *testing.T) {
+	r := newTestRouter()
+	o := createOrder(t, r, "alice")
+	w := do(t, r, http.MethodPost, "/orders/"+o.ID+"/items", `{"product_name":"pen","quantity":2,"price":"1.50"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create item: status = %d: %s", w.Code, w.Body.String())
+	}
+	it := decode[models.OrderItem](t, w)
+
+	if w := do(t, r, http.MethodDelete, "/orders/"+o.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want 204", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/orders/"+o.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted order: status = %d, want 404", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/orders/"+o.ID+"/items/"+it.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("get item of deleted order: status = %d, want 404", w.Code)
+	}
+	// Deleting again is a no-op
+	if w := do(t, r, http.MethodDelete, "/orders/"+o.ID, ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete again: status = %d, want 204", w.Code)
+	}
+}
+
+func TestCreateItemAmountBounds(t *testing.T) {
+	r := newTestRouter()
+	o := createOrder(t, r, "alice")
+	path := "/orders/" + o.ID + "/items"
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"quantity too large", `{"product_name":"pen","quantity":1000001,"price":"1.00"}`, http.StatusBadRequest},
+		{"price too large", `{"product_name":"pen","quantity":1,"price":"100000000000000000"}`, http.StatusBadRequest},
+		{"line total too large", `{"product_name":"pen","quantity":1000000,"price":"9000000000.00"}`, http.StatusUnprocessableEntity},
+		{"within bounds", `{"product_name":"pen","quantity":1000000,"price":"9000000.00"}`, http.StatusCreated},
+		{"order total too large", `{"product_name":"pen","quantity":1000000,"price":"2000000.00"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(t, r, http.MethodPost, path, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+	got := decode[models.Order](t, do(t, r, http.MethodGet, "/orders/"+o.ID, ""))
+	if got.ItemCount != 1 || got.Subtotal.Decimal() != "9000000000000.00" {
+		t.Errorf("order totals = %d items, %s; want 1 item, 9000000000000.00", got.ItemCount, got.Subtotal.Decimal())
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/buildinfo"
+	"go-serverless-api-terraform/internal/health"
+)
+
+// Health serves the liveness and readiness probes.
+type Health struct {
+	ready *health.Readiness
+}
+
+func NewHealth(ready *health.Readiness) *Health {
+	return &Health{ready: ready}
+}
+
+type readinessResp struct {
+	health.Report
+	Build buildinfo.Info `json:"build"`
+}
+
+// Live godoc
+// @Summary Liveness probe
+// @Description Returns 200 while the process is serving requests; checks no dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *Health) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready godoc
+// @Summary Readiness probe
+// @Description Checks the DynamoDB tables with DescribeTable (cached briefly) and reports their status and build info. Returns 503 when a dependency is unavailable.
+// @Tags health
+// @Produce json
+// @Success 200 {object} readinessResp
+// @Failure 503 {object} readinessResp
+// @Router /readyz [get]
+func (h *Health) Ready(c *gin.Context) {
+	report := h.ready.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, readinessResp{Report: report, Build: buildinfo.Get()})
+}
@@ -0,0 +1,99 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type for RFC 7807 problem details.
+const ContentType = "application/problem+json"
+
+// Stable, machine-readable error codes returned in the "code" member.
+const (
+	CodeInvalidRequest        = "invalid_request"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeNotFound              = "not_found"
+	CodeAlreadyExists         = "already_exists"
+	CodeConflict              = "conflict"
+	CodeVersionMismatch       = "version_mismatch"
+	CodeInvalidTransition     = "invalid_transition"
+	CodeAmountOutOfRange      = "amount_out_of_range"
+	CodeThrottled             = "throttled"
+	CodeRateLimited           = "rate_limited"
+	CodeUnavailable           = "unavailable"
+	CodeTimeout               = "timeout"
+	CodePartialDelete         = "partial_delete"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeInternal              = "internal"
+)
+
+// Details is an RFC 7807 problem details body. Extensions are serialized
+// as additional top-level members.
+type Details struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty"`
+	Code       string         `json:"code"`
+	Extensions map[string]any `json:"-"`
+}
+
+// New builds problem details for status and code; the type URI is derived from the code.
+func New(status int, code, detail string) *Details {
+	return &Details{
+		Type:   "urn:orders-api:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With adds an extension member and returns d for chaining.
+func (d *Details) With(key string, value any) *Details {
+	if d.Extensions == nil {
+		d.Extensions = map[string]any{}
+	}
+	d.Extensions[key] = value
+	return d
+}
+
+func (d *Details) MarshalJSON() ([]byte, error) {
+	type plain Details
+	b, err := json.Marshal((*plain)(d))
+	if err != nil || len(d.Extensions) == 0 {
+		return b, err
+	}
+	m := make(map[string]any, len(d.Extensions)+6)
+	for k, v := range d.Extensions {
+		m[k] = v
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// Abort writes d as the response body and stops the handler chain.
+func Abort(c *gin.Context, d *Details) {
+	if d.Instance == "" {
+		d.Instance = c.Request.URL.Path
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(d.Status, ContentType, b)
+	c.Abort()
+}
+
+// Write is shorthand for Abort(c, New(status, code, detail)).
+func Write(c *gin.Context, status int, code, detail string) {
+	Abort(c, New(status, code, detail))
+}
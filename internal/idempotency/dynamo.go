@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore implements Store on a DynamoDB table (PK: key) with TTL enabled on expires_at.
+type DynamoStore struct {
+	db    *dynamodb.Client
+	table string
+}
+
+func NewDynamoStore(db *dynamodb.Client, table string) *DynamoStore {
+	return &DynamoStore{db: db, table: table}
+}
+
+func (s *DynamoStore) Claim(ctx context.Context, key, requestHash, token string, lease time.Duration) (*Record, error) {
+	now := time.Now().UTC()
+	rec := &Record{
+		Key:         key,
+		RequestHash: requestHash,
+		Token:       token,
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(lease).Unix(),
+	}
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return nil, err
+	}
+	// TTL deletion is lazy, so an expired record may still be present and is overwritten.
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                &s.table,
+		Item:                     item,
+		ConditionExpression:      awsString("attribute_not_exists(#key) OR expires_at < :now"),
+		ExpressionAttributeNames: map[string]string{"#key": "key"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) && ccf.Item != nil {
+		var existing Record
+		if err := attributevalue.UnmarshalMap(ccf.Item, &existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	return nil, err
+}
+
+func (s *DynamoStore) Complete(ctx context.Context, rec *Record) error {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 &s.table,
+		Item:                      item,
+		ConditionExpression:       awsString(ownClaim),
+		ExpressionAttributeValues: claimValues(rec.Token),
+	})
+	return leaseErr(err)
+}
+
+func (s *DynamoStore) Release(ctx context.Context, key, token string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 &s.table,
+		Key:                       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+		ConditionExpression:       awsString(ownClaim),
+		ExpressionAttributeValues: claimValues(token),
+	})
+	return leaseErr(err)
+}
+
+// ownClaim matches a record still in progress under the caller's claim.
+const ownClaim = "token = :token AND status_code = :zero"
+
+func claimValues(token string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		":token": &types.AttributeValueMemberS{Value: token},
+		":zero":  &types.AttributeValueMemberN{Value: "0"},
+	}
+}
+
+func leaseErr(err error) error {
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+func awsString(s string) *string { return &s }
@@ -0,0 +1,45 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost is returned by Complete and Release when the claim's lease ran
+// out and another request claimed the key since.
+var ErrLeaseLost = errors.New("idempotency: claim lease lost")
+
+// Record is a stored Idempotency-Key and, once the request has finished, its response.
+type Record struct {
+	Key         string            `dynamodbav:"key"`
+	RequestHash string            `dynamodbav:"request_hash"`
+	Token       string            `dynamodbav:"token"`       // identifies the claim that wrote the record
+	StatusCode  int               `dynamodbav:"status_code"` // 0 while the request is in progress
+	Header      map[string]string `dynamodbav:"header,omitempty"`
+	Body        []byte            `dynamodbav:"body,omitempty"`
+	CreatedAt   string            `dynamodbav:"created_at"`
+	// ExpiresAt is in unix seconds and is the DynamoDB TTL attribute. While the
+	// request is in progress it is the end of the claim's lease.
+	ExpiresAt int64 `dynamodbav:"expires_at"`
+}
+
+// Completed reports whether the original request finished and its response was stored.
+func (r *Record) Completed() bool { return r.StatusCode != 0 }
+
+// Store persists idempotency records.
+type Store interface {
+	// Claim atomically records key as in progress for lease, under a token
+	// unique to the caller. If an unexpired record for key already exists it
+	// is returned instead and nothing is written; an in-progress record whose
+	// lease ran out, e.g. because the process handling it crashed, can be
+	// claimed again.
+	Claim(ctx context.Context, key, requestHash, token string, lease time.Duration) (*Record, error)
+	// Complete stores the response for the claim with rec.Token. It returns
+	// ErrLeaseLost if that claim is no longer in progress.
+	Complete(ctx context.Context, rec *Record) error
+	// Release deletes the claim with token so the request can be retried,
+	// e.g. after a 5xx. It returns ErrLeaseLost if that claim is no longer in
+	// progress.
+	Release(ctx context.Context, key, token string) error
+}
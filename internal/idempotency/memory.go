@@ -0,0 +1,60 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory, for tests and STORAGE=memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key, requestHash, token string, lease time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	if existing, ok := s.records[key]; ok && existing.ExpiresAt >= now.Unix() {
+		return &existing, nil
+	}
+	s.records[key] = Record{
+		Key:         key,
+		RequestHash: requestHash,
+		Token:       token,
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(lease).Unix(),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ownsLocked(rec.Key, rec.Token) {
+		return ErrLeaseLost
+	}
+	s.records[rec.Key] = *rec
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ownsLocked(key, token) {
+		return ErrLeaseLost
+	}
+	delete(s.records, key)
+	return nil
+}
+
+// ownsLocked reports whether key is still in progress under token.
+func (s *MemoryStore) ownsLocked(key, token string) bool {
+	existing, ok := s.records[key]
+	return ok && existing.Token == token && !existing.Completed()
+}
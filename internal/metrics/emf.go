@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EMF writes each measurement as a CloudWatch Embedded Metric Format JSON
+// line. In Lambda, stdout goes to CloudWatch Logs, which extracts the metrics
+// without any API calls from the function.
+type EMF struct {
+	namespace string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewEMF(w io.Writer, namespace string) *EMF {
+	return &EMF{w: w, namespace: namespace}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+func (e *EMF) ObserveRequest(_ context.Context, method, route string, status int, d time.Duration) {
+	e.write(map[string]any{
+		"Method":  method,
+		"Route":   route,
+		"Status":  strconv.Itoa(status),
+		"Latency": float64(d.Microseconds()) / 1000,
+	}, [][]string{{"Route", "Method", "Status"}, {"Route"}}, []emfMetric{{"Latency", "Milliseconds"}})
+}
+
+func (e *EMF) ObserveDynamo(_ context.Context, c DynamoCall) {
+	fields := map[string]any{
+		"Operation":         c.Operation,
+		"DynamoDBLatency":   float64(c.Duration.Microseconds()) / 1000,
+		"DynamoDBErrors":    boolCount(c.Failed),
+		"DynamoDBThrottles": boolCount(c.Throttled),
+	}
+	metrics := []emfMetric{
+		{"DynamoDBLatency", "Milliseconds"},
+		{"DynamoDBErrors", "Count"},
+		{"DynamoDBThrottles", "Count"},
+	}
+	if len(c.Capacity) > 0 {
+		var units float64
+		for _, u := range c.Capacity {
+			units += u
+		}
+		fields["DynamoDBConsumedCapacity"] = units
+		metrics = append(metrics, emfMetric{"DynamoDBConsumedCapacity", "Count"})
+	}
+	// CloudWatch rejects empty dimension values, so multi-table calls have no Table dimension.
+	dims := [][]string{{"Operation"}}
+	if c.Table != "" {
+		fields["Table"] = c.Table
+		dims = [][]string{{"Operation", "Table"}}
+	}
+	e.write(fields, dims, metrics)
+}
+
+func (e *EMF) write(fields map[string]any, dims [][]string, metrics []emfMetric) {
+	fields["_aws"] = map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  e.namespace,
+			"Dimensions": dims,
+			"Metrics":    metrics,
+		}},
+	}
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, _ = e.w.Write(append(line, '\n'))
+}
+
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
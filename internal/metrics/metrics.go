@@ -0,0 +1,35 @@
+// Package metrics records request and DynamoDB metrics, exposed as a
+// Prometheus endpoint in local mode or written as CloudWatch Embedded Metric
+// Format log lines in Lambda mode.
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Recorder receives measurements. Implementations must be safe for concurrent use.
+type Recorder interface {
+	// ObserveRequest records a served HTTP request. route is the route
+	// template, e.g. "/orders/:orderId".
+	ObserveRequest(ctx context.Context, method, route string, status int, d time.Duration)
+	// ObserveDynamo records one DynamoDB API call.
+	ObserveDynamo(ctx context.Context, c DynamoCall)
+}
+
+// DynamoCall describes a completed DynamoDB API call.
+type DynamoCall struct {
+	Operation string // e.g. "GetItem"
+	Table     string // empty for calls spanning tables, e.g. TransactWriteItems
+	Duration  time.Duration
+	Throttled bool // failed with a throttling error after retries
+	Failed    bool
+	// Capacity is the consumed capacity per table, in capacity units.
+	Capacity map[string]float64
+}
+
+// Nop discards all measurements.
+type Nop struct{}
+
+func (Nop) ObserveRequest(context.Context, string, string, int, time.Duration) {}
+func (Nop) ObserveDynamo(context.Context, DynamoCall)                          {}
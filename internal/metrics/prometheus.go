@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus keeps metrics in a Prometheus registry served by Handler.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	requests    *prometheus.HistogramVec
+	dynamo      *prometheus.HistogramVec
+	dynamoFails *prometheus.CounterVec
+	throttles   *prometheus.CounterVec
+	capacity    *prometheus.CounterVec
+}
+
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route template and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dynamo: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dynamodb_operation_duration_seconds",
+			Help:    "DynamoDB call latency, including SDK retries.",
+			Buckets: []float64{.002, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		dynamoFails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dynamodb_operation_errors_total",
+			Help: "DynamoDB calls that returned an error, including conditional check failures.",
+		}, []string{"operation", "table"}),
+		throttles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dynamodb_throttles_total",
+			Help: "DynamoDB calls that were still throttled after SDK retries.",
+		}, []string{"operation", "table"}),
+		capacity: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dynamodb_consumed_capacity_units_total",
+			Help: "Capacity units consumed, by table (including its indexes).",
+		}, []string{"operation", "table"}),
+	}
+	p.registry.MustRegister(
+		p.requests, p.dynamo, p.dynamoFails, p.throttles, p.capacity,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return p
+}
+
+// Handler serves the registry in the Prometheus text or OpenMetrics format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+func (p *Prometheus) ObserveRequest(_ context.Context, method, route string, status int, d time.Duration) {
+	p.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+func (p *Prometheus) ObserveDynamo(_ context.Context, c DynamoCall) {
+	p.dynamo.WithLabelValues(c.Operation, c.Table).Observe(c.Duration.Seconds())
+	if c.Failed {
+		p.dynamoFails.WithLabelValues(c.Operation, c.Table).Inc()
+	}
+	if c.Throttled {
+		p.throttles.WithLabelValues(c.Operation, c.Table).Inc()
+	}
+	for table, units := range c.Capacity {
+		p.capacity.WithLabelValues(c.Operation, table).Add(units)
+	}
+}
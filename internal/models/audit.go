@@ -0,0 +1,36 @@
+package models
+
+// Audit operations
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEvent records one change to an order or one of its items.
+// Stored in DynamoDB table configured by TABLE_ORDER_EVENTS (PK: order_id,
+// SK: at), with "#<id>" appended to the stored at so simultaneous events
+// cannot overwrite each other.
+type AuditEvent struct {
+	OrderID string `json:"order_id" dynamodbav:"order_id"`
+	ID      string `json:"id,omitempty" dynamodbav:"id,omitempty"`
+	// At is an RFC 3339 timestamp with fixed-width nanoseconds, so events
+	// sort chronologically. It comes from the clock of the instance making
+	// the change, so changes made on different instances within their clock
+	// skew may be listed out of order.
+	At string `json:"at" dynamodbav:"at"`
+	// Actor is the subject of the caller; empty for changes made outside a request
+	Actor     string `json:"actor,omitempty" dynamodbav:"actor,omitempty"`
+	Operation string `json:"operation" dynamodbav:"operation"`
+	// ItemID is set when the change is to an item rather than the order itself
+	ItemID  string        `json:"item_id,omitempty" dynamodbav:"item_id,omitempty"`
+	Changes []FieldChange `json:"changes" dynamodbav:"changes"`
+}
+
+// FieldChange is the before and after value of one field, in its JSON form.
+// Before is omitted for created fields and After for deleted ones.
+type FieldChange struct {
+	Field  string `json:"field" dynamodbav:"field"`
+	Before any    `json:"before,omitempty" dynamodbav:"before,omitempty"`
+	After  any    `json:"after,omitempty" dynamodbav:"after,omitempty"`
+}
@@ -3,21 +3,55 @@ package models
 // Order represents a customer order
 // Stored in DynamoDB table configured by TABLE_ORDERS (PK: id)
 type Order struct {
-	ID           string `json:"id"`
-	CustomerName string `json:"customer_name"`
-	Status       string `json:"status"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ID           string `json:"id" dynamodbav:"id"`
+	CustomerName string `json:"customer_name" dynamodbav:"customer_name"`
+	Status       string `json:"status" dynamodbav:"status"`
+	CreatedAt    string `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt    string `json:"updated_at" dynamodbav:"updated_at"`
+	// OwnerID is the subject of the caller that created the order; empty when
+	// authentication is disabled. Omitted when empty so the owner index stays sparse.
+	OwnerID string `json:"owner_id,omitempty" dynamodbav:"owner_id,omitempty"`
+	// Totals are maintained by the repository as items are added, changed or removed.
+	// Their currency is the order's currency; every item price must use it.
+	// Total equals Subtotal until taxes, shipping or discounts are modeled.
+	ItemCount int   `json:"item_count" dynamodbav:"item_count"`
+	Subtotal  Money `json:"subtotal" dynamodbav:"subtotal"`
+	Total     Money `json:"total" dynamodbav:"total"`
+	// Version increments on every write and backs optimistic concurrency (ETag/If-Match)
+	Version int64 `json:"version" dynamodbav:"version"`
 }
 
 // OrderItem represents an item within an Order
 // Stored in DynamoDB table configured by TABLE_ORDER_ITEMS (PK: order_id, SK: id)
 type OrderItem struct {
-	OrderID     string  `json:"order_id"`
-	ID          string  `json:"id"`
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	OrderID     string `json:"order_id" dynamodbav:"order_id"`
+	ID          string `json:"id" dynamodbav:"id"`
+	ProductName string `json:"product_name" dynamodbav:"product_name"`
+	Quantity    int    `json:"quantity" dynamodbav:"quantity"`
+	Price       Money  `json:"price" dynamodbav:"price"`
+	CreatedAt   string `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt   string `json:"updated_at" dynamodbav:"updated_at"`
+	// Version increments on every write and backs optimistic concurrency (ETag/If-Match)
+	Version int64 `json:"version" dynamodbav:"version"`
+}
+
+// Currency returns the order's currency, taken from its totals.
+func (o Order) Currency() string {
+	if o.Subtotal.Currency == "" {
+		return DefaultCurrency
+	}
+	return o.Subtotal.Currency
+}
+
+// TimeLayout is RFC 3339 with fixed-width nanoseconds; unlike
+// time.RFC3339Nano it keeps trailing zeros, so UTC timestamps formatted with
+// it sort chronologically as strings. Audit and outbox events use it.
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// MaxQuantity bounds OrderItem.Quantity.
+const MaxQuantity = 1_000_000
+
+// LineTotal is the item's contribution to its order's subtotal.
+func (it OrderItem) LineTotal() (Money, error) {
+	return it.Price.Mul(it.Quantity)
 }
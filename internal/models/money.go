@@ -0,0 +1,300 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultCurrency is assumed for legacy float prices and for orders created without a currency.
+const DefaultCurrency = "USD"
+
+// currencyExponents maps supported ISO 4217 codes to their number of minor-unit digits.
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "SEK": 2,
+	"SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "USD": 2, "VND": 0,
+	"ZAR": 2,
+}
+
+// MaxAmount bounds every amount in minor units, prices and order totals
+// alike. It is far below the int64 range, so Add and Mul can detect overflow
+// before it happens, and totals DynamoDB adds up stay parseable.
+const MaxAmount int64 = 1_000_000_000_000_000
+
+// ErrAmountOutOfRange is returned when an amount, or a sum or product of
+// amounts, exceeds MaxAmount.
+var ErrAmountOutOfRange = errors.New("amount out of range")
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// Money is an exact amount in the minor units of a currency (e.g. cents).
+//
+// JSON: {"amount": "99.99", "currency": "USD"}; amount is a decimal string,
+// and a bare JSON number is also accepted on input (see DecodeMoneyJSON).
+// DynamoDB: a map {amount: N (minor units), currency: S}; a legacy bare N
+// holding a float price in major units is read as DefaultCurrency.
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217 code
+}
+
+// ParseMoney parses a decimal amount in major units (e.g. "99.99") without going through float64.
+func ParseMoney(amount, currency string) (Money, error) {
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+	minor, err := parseMinor(amount, exp, false)
+	if err != nil {
+		return Money{}, err
+	}
+	if err := checkRange(minor); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// IsZero reports whether m is the zero value (no amount and no currency).
+func (m Money) IsZero() bool { return m == Money{} }
+
+// Add returns m + o; both must share a currency (or one be the zero value).
+// It fails with ErrAmountOutOfRange if the sum exceeds MaxAmount.
+func (m Money) Add(o Money) (Money, error) {
+	if m.IsZero() {
+		return o, nil
+	}
+	if o.IsZero() {
+		return m, nil
+	}
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("currency mismatch: %s and %s", m.Currency, o.Currency)
+	}
+	if o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount || o.Amount < 0 && m.Amount < math.MinInt64-o.Amount {
+		return Money{}, ErrAmountOutOfRange
+	}
+	sum := m.Amount + o.Amount
+	if err := checkRange(sum); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n, failing with ErrAmountOutOfRange if the
+// product exceeds MaxAmount.
+func (m Money) Mul(n int) (Money, error) {
+	q := int64(n)
+	if err := checkRange(q); err != nil {
+		return Money{}, err
+	}
+	if q < 0 {
+		m, q = m.Neg(), -q
+	}
+	if q != 0 && (m.Amount > MaxAmount/q || m.Amount < -MaxAmount/q) {
+		return Money{}, ErrAmountOutOfRange
+	}
+	return Money{Amount: m.Amount * q, Currency: m.Currency}, nil
+}
+
+func checkRange(amount int64) error {
+	if amount > MaxAmount || amount < -MaxAmount {
+		return ErrAmountOutOfRange
+	}
+	return nil
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Decimal formats the amount in major units, e.g. "99.99" or "-0.05".
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	sign, abs := "", m.Amount
+	if abs < 0 {
+		sign, abs = "-", -abs
+	}
+	s := strconv.FormatInt(abs, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string { return m.Decimal() + " " + m.Currency }
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "12.34" | 12.34, "currency": "USD"}, or a
+// bare number, which is read in DefaultCurrency. See DecodeMoneyJSON.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if strings.TrimSpace(string(b)) == "null" {
+		return nil
+	}
+	parsed, err := DecodeMoneyJSON(b, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// DecodeMoneyJSON decodes a JSON money value. A bare number, or an object
+// without a currency, is read as an amount in currency, so legacy clients
+// sending "price": 99.99 keep working.
+func DecodeMoneyJSON(b []byte, currency string) (Money, error) {
+	b = []byte(strings.TrimSpace(string(b)))
+	if len(b) > 0 && b[0] != '{' {
+		var n json.Number
+		if err := json.Unmarshal(b, &n); err != nil {
+			return Money{}, errors.New("money must be an object or a number")
+		}
+		return ParseMoney(n.String(), currency)
+	}
+	var raw moneyJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Money{}, err
+	}
+	var amount string
+	if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(raw.Amount, &n); err != nil {
+			return Money{}, errors.New("money amount must be a decimal string or number")
+		}
+		amount = n.String()
+	}
+	if raw.Currency != "" {
+		currency = raw.Currency
+	}
+	return ParseMoney(amount, currency)
+}
+
+func (m Money) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"amount":   &types.AttributeValueMemberN{Value: strconv.FormatInt(m.Amount, 10)},
+		"currency": &types.AttributeValueMemberS{Value: m.Currency},
+	}}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue reads the map form, or a legacy float price
+// (N in major units) as DefaultCurrency.
+func (m *Money) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberN:
+		minor, err := parseMinor(v.Value, currencyExponents[DefaultCurrency], true)
+		if err != nil {
+			return fmt.Errorf("legacy price %q: %w", v.Value, err)
+		}
+		*m = Money{Amount: minor, Currency: DefaultCurrency}
+		return nil
+	case *types.AttributeValueMemberM:
+		amount, ok := v.Value["amount"].(*types.AttributeValueMemberN)
+		if !ok {
+			return errors.New("money: missing amount")
+		}
+		currency, ok := v.Value["currency"].(*types.AttributeValueMemberS)
+		if !ok {
+			return errors.New("money: missing currency")
+		}
+		minor, err := strconv.ParseInt(amount.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("money amount: %w", err)
+		}
+		*m = Money{Amount: minor, Currency: currency.Value}
+		return nil
+	}
+	return fmt.Errorf("money: unsupported attribute type %T", av)
+}
+
+// IsLegacyMoney reports whether av is a pre-Money float price that should be migrated.
+func IsLegacyMoney(av types.AttributeValue) bool {
+	_, ok := av.(*types.AttributeValueMemberN)
+	return ok
+}
+
+// parseMinor converts a decimal string in major units to minor units with exp digits.
+// Extra precision is rejected, or rounded half away from zero when round is set
+// (for legacy float values such as "199.98000000000002"). Exponent notation, as
+// DynamoDB may return for legacy numbers, is supported.
+func parseMinor(s string, exp int, round bool) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty amount")
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		if e < -30 || e > 18 {
+			return 0, fmt.Errorf("amount %q out of range", s)
+		}
+		s, exp = s[:i], exp+e
+	}
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	digits := whole + frac
+	shift := exp - len(frac)
+	roundUp := false
+	if shift < 0 {
+		if len(digits) < -shift {
+			digits = strings.Repeat("0", -shift-len(digits)) + digits
+		}
+		dropped := digits[len(digits)+shift:]
+		if strings.Trim(dropped, "0") != "" {
+			if !round {
+				return 0, fmt.Errorf("amount %q has more than %d decimal places", s, exp)
+			}
+			roundUp = dropped[0] >= '5'
+		}
+		digits = digits[:len(digits)+shift]
+	} else {
+		digits += strings.Repeat("0", shift)
+	}
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	n, err := strconv.ParseUint(digits, 10, 63)
+	if err != nil || n >= math.MaxInt64 {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+	if roundUp {
+		n++
+	}
+	if neg {
+		return -int64(n), nil
+	}
+	return int64(n), nil
+}
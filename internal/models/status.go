@@ -0,0 +1,47 @@
+package models
+
+// Order lifecycle statuses
+const (
+	StatusNew       = "new"
+	StatusConfirmed = "confirmed"
+	StatusPaid      = "paid"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+	StatusRefunded  = "refunded"
+)
+
+// statusTransitions lists, for each status, the statuses an order may move to.
+// The happy path is new -> confirmed -> paid -> shipped -> delivered; unpaid
+// orders can be cancelled, paid or delivered orders can be refunded.
+// cancelled and refunded are terminal.
+var statusTransitions = map[string][]string{
+	StatusNew:       {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+	StatusCancelled: {},
+	StatusRefunded:  {},
+}
+
+// ValidStatus reports whether s is a known order status.
+func ValidStatus(s string) bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// NextStatuses returns the statuses an order in status from may transition to.
+func NextStatuses(from string) []string {
+	return append([]string(nil), statusTransitions[from]...)
+}
+
+// CanTransition reports whether an order may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// DynamoStore implements Store on a DynamoDB table (PK: id). The table only
+// holds events that have not been published yet, and the few parked ones, so
+// it stays small and Pending can scan it.
+type DynamoStore struct {
+	db    *dynamodb.Client
+	table string
+}
+
+func NewDynamoStore(db *dynamodb.Client, table string) *DynamoStore {
+	return &DynamoStore{db: db, table: table}
+}
+
+// Put returns the transaction operation that adds e to the outbox table.
+func Put(table string, e Event) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(e)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	return types.TransactWriteItem{Put: &types.Put{TableName: &table, Item: item}}, nil
+}
+
+// Pending scans one page of the table. The scan follows the hash of the
+// event IDs, so only the events within a page are in time order.
+func (s *DynamoStore) Pending(ctx context.Context, cursor string, limit int) ([]Event, string, error) {
+	in := &dynamodb.ScanInput{
+		TableName:        &s.table,
+		ConsistentRead:   awsBool(true),
+		FilterExpression: awsString("attribute_not_exists(parked_at)"),
+	}
+	if cursor != "" {
+		in.ExclusiveStartKey = eventKey(cursor)
+	}
+	if limit > 0 {
+		n := int32(limit)
+		in.Limit = &n
+	}
+	res, err := s.db.Scan(ctx, in)
+	if err != nil {
+		return nil, "", err
+	}
+	var out []Event
+	if err := attributevalue.UnmarshalListOfMaps(res.Items, &out); err != nil {
+		return nil, "", err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].OccurredAt < out[j].OccurredAt })
+	next := ""
+	if id, ok := res.LastEvaluatedKey["id"].(*types.AttributeValueMemberS); ok {
+		next = id.Value
+	}
+	return out, next, nil
+}
+
+func (s *DynamoStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &s.table,
+		Key:       eventKey(id),
+	})
+	return err
+}
+
+// Fail counts the attempt with an atomic ADD, then marks the event parked
+// once the count reaches maxAttempts.
+func (s *DynamoStore) Fail(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	res, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           &s.table,
+		Key:                 eventKey(id),
+		UpdateExpression:    awsString("ADD attempts :one"),
+		ConditionExpression: awsString("attribute_exists(id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var state struct {
+		Attempts int `dynamodbav:"attempts"`
+	}
+	if err := attributevalue.UnmarshalMap(res.Attributes, &state); err != nil {
+		return false, err
+	}
+	if maxAttempts <= 0 || state.Attempts < maxAttempts {
+		return false, nil
+	}
+	_, err = s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           &s.table,
+		Key:                 eventKey(id),
+		UpdateExpression:    awsString("SET parked_at = :now"),
+		ConditionExpression: awsString("attribute_exists(id) AND attribute_not_exists(parked_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(models.TimeLayout)},
+		},
+	})
+	if errors.As(err, &condErr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func eventKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func awsBool(b bool) *bool { return &b }
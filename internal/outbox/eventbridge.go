@@ -0,0 +1,57 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// EventBridgePublisher puts events on an EventBridge bus. The event type is
+// the detail-type and the whole event the detail, so rules can match e.g.
+// {"source": ["orders-api"], "detail-type": ["OrderStatusChanged"]}.
+type EventBridgePublisher struct {
+	client *eventbridge.Client
+	bus    string
+	source string
+}
+
+func NewEventBridgePublisher(client *eventbridge.Client, bus, source string) *EventBridgePublisher {
+	return &EventBridgePublisher{client: client, bus: bus, source: source}
+}
+
+func (p *EventBridgePublisher) Publish(ctx context.Context, e Event) error {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	entry := types.PutEventsRequestEntry{
+		EventBusName: &p.bus,
+		Source:       &p.source,
+		DetailType:   &e.Type,
+		Detail:       awsString(string(detail)),
+	}
+	if t, err := time.Parse(time.RFC3339Nano, e.OccurredAt); err == nil {
+		entry.Time = &t
+	}
+	out, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: []types.PutEventsRequestEntry{entry}})
+	if err != nil {
+		return err
+	}
+	// PutEvents reports per-entry failures in a successful response.
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		res := out.Entries[0]
+		return fmt.Errorf("eventbridge rejected event: %s: %s", deref(res.ErrorCode), deref(res.ErrorMessage))
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// MemoryStore implements Store in process memory, for tests and STORAGE=memory.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []memoryEvent // in the order they were added
+	seq    int
+}
+
+type memoryEvent struct {
+	seq int // position in the store, used as the page cursor
+	Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add appends events to the outbox.
+func (s *MemoryStore) Add(events ...Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		s.seq++
+		s.events = append(s.events, memoryEvent{seq: s.seq, Event: e})
+	}
+}
+
+// Pending returns events in the order they were added.
+func (s *MemoryStore) Pending(ctx context.Context, cursor string, limit int) ([]Event, string, error) {
+	after := 0
+	if cursor != "" {
+		var err error
+		if after, err = strconv.Atoi(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Event
+	for _, e := range s.events {
+		if e.seq <= after || e.ParkedAt != "" {
+			continue
+		}
+		if limit > 0 && len(out) == limit {
+			return out, strconv.Itoa(after), nil
+		}
+		out = append(out, e.Event)
+		after = e.seq
+	}
+	return out, "", nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.events {
+		if e.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Fail(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.events {
+		e := &s.events[i]
+		if e.ID != id {
+			continue
+		}
+		e.Attempts++
+		if maxAttempts > 0 && e.Attempts >= maxAttempts && e.ParkedAt == "" {
+			e.ParkedAt = time.Now().UTC().Format(models.TimeLayout)
+			return true, nil
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// Parked returns the events the relay gave up on.
+func (s *MemoryStore) Parked() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Event
+	for _, e := range s.events {
+		if e.ParkedAt != "" {
+			out = append(out, e.Event)
+		}
+	}
+	return out
+}
+
+// MemoryPublisher implements EventPublisher by keeping every event, for tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Events returns the published events in order.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
@@ -0,0 +1,93 @@
+// Package outbox implements the transactional outbox for domain events.
+//
+// The repository writes an Event for every order and item change in the same
+// transaction as the change itself, so an event exists if and only if the
+// change committed. A Relay then reads the pending events and hands them to an
+// EventPublisher (SNS, EventBridge, SQS or in memory), deleting each one once
+// it was published. Delivery is therefore at least once: consumers should
+// deduplicate by Event.ID. Events that keep failing to publish are parked in
+// the store rather than retried forever.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// Event types
+const (
+	OrderCreated       = "OrderCreated"
+	OrderUpdated       = "OrderUpdated"
+	OrderStatusChanged = "OrderStatusChanged"
+	OrderDeleted       = "OrderDeleted"
+	ItemAdded          = "ItemAdded"
+	ItemUpdated        = "ItemUpdated"
+	ItemRemoved        = "ItemRemoved"
+)
+
+// Event is a domain event as stored in the outbox and published.
+// Stored in DynamoDB table configured by TABLE_OUTBOX (PK: id)
+type Event struct {
+	ID         string `json:"id" dynamodbav:"id"`
+	Type       string `json:"type" dynamodbav:"type"`
+	OrderID    string `json:"order_id" dynamodbav:"order_id"`
+	ItemID     string `json:"item_id,omitempty" dynamodbav:"item_id,omitempty"`
+	TenantID   string `json:"tenant_id,omitempty" dynamodbav:"tenant_id,omitempty"`
+	OccurredAt string `json:"occurred_at" dynamodbav:"occurred_at"`
+	// Data is the order or item after the change (before it, for deletes),
+	// or a StatusChange for OrderStatusChanged.
+	Data json.RawMessage `json:"data" dynamodbav:"data"`
+
+	// Delivery state, kept out of published messages
+	Attempts int    `json:"-" dynamodbav:"attempts,omitempty"`  // failed publish attempts
+	ParkedAt string `json:"-" dynamodbav:"parked_at,omitempty"` // set once the relay gave up on the event
+}
+
+// StatusChange is the data of an OrderStatusChanged event.
+type StatusChange struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Order models.Order `json:"order"`
+}
+
+// NewEvent returns an event of type typ with a new ID, occurring now.
+func NewEvent(typ, orderID, itemID string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       typ,
+		OrderID:    orderID,
+		ItemID:     itemID,
+		OccurredAt: time.Now().UTC().Format(models.TimeLayout),
+		Data:       raw,
+	}, nil
+}
+
+// Store holds events that have not been published yet.
+type Store interface {
+	// Pending returns a page of up to limit events that are not parked,
+	// starting after cursor ("" for the first page), and the cursor of the
+	// next page, or "" after the last one. Events are sorted by OccurredAt
+	// within a page, but pages come in no particular order.
+	Pending(ctx context.Context, cursor string, limit int) ([]Event, string, error)
+	// Delete removes a published event. Deleting a missing event is not an error.
+	Delete(ctx context.Context, id string) error
+	// Fail records a failed attempt to publish an event and parks the event
+	// once it has failed maxAttempts times, reporting whether it did. A
+	// maxAttempts of 0 only counts the attempt. Failing a missing event is
+	// not an error.
+	Fail(ctx context.Context, id string, maxAttempts int) (bool, error)
+}
+
+// EventPublisher delivers events to downstream consumers.
+type EventPublisher interface {
+	Publish(ctx context.Context, e Event) error
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"go-serverless-api-terraform/internal/config"
+)
+
+// NewPublisher returns the publisher selected by OUTBOX_PUBLISHER, or nil for none.
+func NewPublisher(ctx context.Context, cfg *config.Config) (EventPublisher, error) {
+	switch cfg.OutboxPublisher {
+	case "none":
+		return nil, nil
+	case "memory":
+		return &MemoryPublisher{}, nil
+	}
+	// DYNAMODB_ENDPOINT only applies to DynamoDB, so this loads a plain config.
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.AWSRegion))
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.OutboxPublisher {
+	case "sns":
+		return NewSNSPublisher(sns.NewFromConfig(awsCfg), cfg.OutboxTarget), nil
+	case "sqs":
+		return NewSQSPublisher(sqs.NewFromConfig(awsCfg), cfg.OutboxTarget), nil
+	case "eventbridge":
+		return NewEventBridgePublisher(eventbridge.NewFromConfig(awsCfg), cfg.OutboxTarget, cfg.OutboxEventSource), nil
+	}
+	return nil, fmt.Errorf("unknown outbox publisher %q", cfg.OutboxPublisher)
+}
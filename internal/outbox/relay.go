@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-serverless-api-terraform/internal/logging"
+)
+
+// Relay moves events from a Store to an EventPublisher. It publishes events
+// one at a time, in the order Store.Pending returns them. An event that fails
+// to publish is retried on the next pass, and so are the events of the same
+// order that come after it in this pass; other orders' events carry on.
+// Pages come in no particular order, so an order's events in different pages
+// may still be published out of order. Once an event has failed maxAttempts
+// times it is parked and no longer holds up its order.
+//
+// Every failure counts as an attempt, but a pass that published nothing while
+// failing events of several orders looks like an outage of the publisher, and
+// parks nothing.
+type Relay struct {
+	store       Store
+	pub         EventPublisher
+	interval    time.Duration
+	maxAttempts int
+}
+
+const (
+	// batchSize is how many events Drain reads from the store at a time.
+	batchSize = 100
+	// outageFailures ends a pass that has published nothing after this many
+	// failures, as the publisher is likely down.
+	outageFailures = 5
+)
+
+func NewRelay(store Store, pub EventPublisher, interval time.Duration, maxAttempts int) *Relay {
+	return &Relay{store: store, pub: pub, interval: interval, maxAttempts: maxAttempts}
+}
+
+// Drain makes one pass over the pending events, publishing and then deleting
+// each, and returns how many were published. Publish failures do not stop
+// the pass; they are reported together at the end.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	log := logging.FromContext(ctx)
+	var (
+		published int
+		failed    []Event
+		firstErr  error
+		held      = map[string]bool{} // orders with an event left for the next pass
+		cursor    string
+	)
+pass:
+	for {
+		events, next, err := r.store.Pending(ctx, cursor, batchSize)
+		if err != nil {
+			return published, fmt.Errorf("read outbox: %w", err)
+		}
+		for _, e := range events {
+			order := e.TenantID + "/" + e.OrderID
+			if held[order] {
+				continue
+			}
+			if err := r.pub.Publish(ctx, e); err != nil {
+				held[order] = true
+				failed = append(failed, e)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("publish event %s: %w", e.ID, err)
+				}
+				if published == 0 && len(failed) >= outageFailures {
+					break pass
+				}
+				continue
+			}
+			// A failed delete publishes the event again on the next pass.
+			if err := r.store.Delete(ctx, e.ID); err != nil {
+				return published, fmt.Errorf("delete event %s: %w", e.ID, err)
+			}
+			published++
+			log.DebugContext(ctx, "event published", "event_id", e.ID, "type", e.Type, "order_id", e.OrderID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(failed) == 0 {
+		return published, nil
+	}
+	maxAttempts := r.maxAttempts
+	if published == 0 && len(held) > 1 {
+		maxAttempts = 0
+	}
+	for _, e := range failed {
+		parked, err := r.store.Fail(ctx, e.ID, maxAttempts)
+		if err != nil {
+			return published, fmt.Errorf("record failure of event %s: %w", e.ID, err)
+		}
+		if parked {
+			log.ErrorContext(ctx, "event parked after repeated publish failures",
+				"event_id", e.ID, "type", e.Type, "order_id", e.OrderID, "tenant", e.TenantID, "attempts", r.maxAttempts)
+		}
+	}
+	return published, fmt.Errorf("%d events failed to publish, first: %w", len(failed), firstErr)
+}
+
+// Run drains the outbox every interval until ctx is done. Failures are
+// logged and retried on the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	log := logging.FromContext(ctx)
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			log.WarnContext(ctx, "outbox relay failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// flakyPublisher fails the events fail picks and records the rest.
+type flakyPublisher struct {
+	fail  func(Event) bool
+	calls int
+	MemoryPublisher
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, e Event) error {
+	p.calls++
+	if p.fail(e) {
+		return errors.New("rejected")
+	}
+	return p.MemoryPublisher.Publish(ctx, e)
+}
+
+func event(id, orderID string) Event {
+	return Event{ID: id, Type: OrderUpdated, OrderID: orderID, OccurredAt: time.Now().UTC().Format(models.TimeLayout)}
+}
+
+func ids(events []Event) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.ID
+	}
+	return out
+}
+
+func TestRelayParksFailingEvent(t *testing.T) {
+	store := NewMemoryStore()
+	pub := &flakyPublisher{fail: func(e Event) bool { return e.ID == "a1" }}
+	relay := NewRelay(store, pub, time.Second, 2)
+	store.Add(event("a1", "a"), event("a2", "a"), event("b1", "b"))
+
+	// a1 fails and holds back a2; b1 goes out
+	n, err := relay.Drain(t.Context())
+	if n != 1 || err == nil {
+		t.Fatalf("Drain = %d, %v; want 1 and an error for a1", n, err)
+	}
+	store.Add(event("c1", "c"))
+	if n, _ := relay.Drain(t.Context()); n != 1 {
+		t.Fatalf("second Drain published %d, want 1", n)
+	}
+	if parked := store.Parked(); len(parked) != 1 || parked[0].ID != "a1" || parked[0].Attempts != 2 {
+		t.Fatalf("parked = %+v, want a1 after 2 attempts", parked)
+	}
+	// Once a1 is parked, a2 is no longer held back
+	if n, err := relay.Drain(t.Context()); n != 1 || err != nil {
+		t.Fatalf("third Drain = %d, %v; want 1, nil", n, err)
+	}
+	if got := fmt.Sprint(ids(pub.Events())); got != "[b1 c1 a2]" {
+		t.Errorf("published %s, want [b1 c1 a2]", got)
+	}
+	if pending, _, _ := store.Pending(t.Context(), "", 0); len(pending) != 0 {
+		t.Errorf("pending = %v, want none", ids(pending))
+	}
+}
+
+func TestRelayParksEventBlockingItsOrder(t *testing.T) {
+	store := NewMemoryStore()
+	pub := &flakyPublisher{fail: func(e Event) bool { return e.ID == "a1" }}
+	relay := NewRelay(store, pub, time.Second, 3)
+	store.Add(event("a1", "a"), event("a2", "a"))
+
+	// Nothing else is pending, so no pass publishes anything until a1 is parked
+	for range 3 {
+		if n, err := relay.Drain(t.Context()); n != 0 || err == nil {
+			t.Fatalf("Drain = %d, %v; want 0 and an error for a1", n, err)
+		}
+	}
+	if parked := store.Parked(); len(parked) != 1 || parked[0].ID != "a1" {
+		t.Fatalf("parked = %v, want a1", ids(parked))
+	}
+	if n, err := relay.Drain(t.Context()); n != 1 || err != nil {
+		t.Errorf("Drain after parking = %d, %v; want a2 published", n, err)
+	}
+}
+
+func TestRelayOutageParksNothing(t *testing.T) {
+	store := NewMemoryStore()
+	pub := &flakyPublisher{fail: func(Event) bool { return true }}
+	relay := NewRelay(store, pub, time.Second, 1)
+	for i := range 20 {
+		store.Add(event(fmt.Sprint("e", i), fmt.Sprint("o", i)))
+	}
+	for range 3 {
+		if n, err := relay.Drain(t.Context()); n != 0 || err == nil {
+			t.Fatalf("Drain = %d, %v; want 0 and an error", n, err)
+		}
+	}
+	if pub.calls != 3*outageFailures {
+		t.Errorf("publisher called %d times, want %d: each pass should stop after %d failures", pub.calls, 3*outageFailures, outageFailures)
+	}
+	if parked := store.Parked(); len(parked) != 0 {
+		t.Errorf("parked %v during an outage, want none", ids(parked))
+	}
+}
+
+func TestRelayDrainsAllPages(t *testing.T) {
+	store := NewMemoryStore()
+	pub := &flakyPublisher{fail: func(Event) bool { return false }}
+	relay := NewRelay(store, pub, time.Second, 1)
+	for i := range 2*batchSize + 10 {
+		store.Add(event(fmt.Sprint("e", i), "o1"))
+	}
+	if n, err := relay.Drain(t.Context()); n != 2*batchSize+10 || err != nil {
+		t.Errorf("Drain = %d, %v; want %d, nil", n, err, 2*batchSize+10)
+	}
+}
@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNSPublisher publishes events as JSON messages to an SNS topic. The event
+// type is sent as the "type" message attribute for subscription filter
+// policies. FIFO topics get the order as message group, so each order's
+// events are delivered in order, and the event ID for deduplication.
+type SNSPublisher struct {
+	client   *sns.Client
+	topicARN string
+}
+
+func NewSNSPublisher(client *sns.Client, topicARN string) *SNSPublisher {
+	return &SNSPublisher{client: client, topicARN: topicARN}
+}
+
+func (p *SNSPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	in := &sns.PublishInput{
+		TopicArn: &p.topicARN,
+		Message:  awsString(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"type": {DataType: awsString("String"), StringValue: &e.Type},
+		},
+	}
+	if strings.HasSuffix(p.topicARN, ".fifo") {
+		in.MessageGroupId = awsString(groupID(e))
+		in.MessageDeduplicationId = &e.ID
+	}
+	_, err = p.client.Publish(ctx, in)
+	return err
+}
+
+// groupID is the FIFO message group of e: its order, within its tenant.
+func groupID(e Event) string {
+	if e.TenantID == "" {
+		return e.OrderID
+	}
+	return e.TenantID + "#" + e.OrderID
+}
+
+func awsString(s string) *string { return &s }
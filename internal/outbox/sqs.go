@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQSPublisher sends events as JSON messages to an SQS queue, with the event
+// type as the "type" message attribute. Like SNSPublisher, FIFO queues group
+// messages by order and deduplicate them by event ID.
+type SQSPublisher struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+func NewSQSPublisher(client *sqs.Client, queueURL string) *SQSPublisher {
+	return &SQSPublisher{client: client, queueURL: queueURL}
+}
+
+func (p *SQSPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	in := &sqs.SendMessageInput{
+		QueueUrl:    &p.queueURL,
+		MessageBody: awsString(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"type": {DataType: awsString("String"), StringValue: &e.Type},
+		},
+	}
+	if strings.HasSuffix(p.queueURL, ".fifo") {
+		in.MessageGroupId = awsString(groupID(e))
+		in.MessageDeduplicationId = &e.ID
+	}
+	_, err = p.client.SendMessage(ctx, in)
+	return err
+}
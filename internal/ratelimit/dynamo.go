@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoLimiter counts requests in fixed windows on a DynamoDB table (PK:
+// key) with TTL enabled on expires_at, so limits hold across Lambda
+// instances. Each request is one atomic UpdateItem ADD on the counter of
+// "<key>#<window start>"; counters expire shortly after their window ends.
+type DynamoLimiter struct {
+	db    *dynamodb.Client
+	table string
+	now   func() time.Time
+}
+
+func NewDynamoLimiter(db *dynamodb.Client, table string) *DynamoLimiter {
+	return &DynamoLimiter{db: db, table: table, now: time.Now}
+}
+
+func (d *DynamoLimiter) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	now := d.now()
+	start := now.Truncate(l.Window)
+	end := start.Add(l.Window)
+	res, err := d.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &d.table,
+		Key: map[string]types.AttributeValue{
+			"key": &types.AttributeValueMemberS{Value: key + "#" + strconv.FormatInt(start.Unix(), 10)},
+		},
+		UpdateExpression:         awsString("ADD #count :one SET expires_at = if_not_exists(expires_at, :exp)"),
+		ExpressionAttributeNames: map[string]string{"#count": "count"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":exp": &types.AttributeValueMemberN{Value: strconv.FormatInt(end.Add(time.Minute).Unix(), 10)},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	count := 0
+	if n, ok := res.Attributes["count"].(*types.AttributeValueMemberN); ok {
+		count, _ = strconv.Atoi(n.Value)
+	}
+	reset := end.Sub(now)
+	out := Result{Allowed: count <= l.Requests, Remaining: max(l.Requests-count, 0), Reset: reset}
+	if !out.Allowed {
+		out.RetryAfter = reset
+	}
+	return out, nil
+}
+
+func awsString(s string) *string { return &s }
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxBuckets bounds the in-process state; full buckets are evicted when it is reached.
+const maxBuckets = 100000
+
+// MemoryLimiter is a token bucket per key, held in process memory. Each key
+// may burst up to Limit.Requests, refilled evenly over Limit.Window. Limits
+// are per instance, so in Lambda use DynamoLimiter instead.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, l Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	rate := float64(l.Requests) / l.Window.Seconds() // tokens per second
+
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= maxBuckets {
+			m.evict(now)
+		}
+		b = &bucket{tokens: float64(l.Requests), updated: now, limit: l}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.Requests), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated, b.limit = now, l
+
+	res := Result{Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(l.Requests) - b.tokens) / rate)
+	return res, nil
+}
+
+// evict drops buckets that have refilled completely; they hold no state.
+func (m *MemoryLimiter) evict(now time.Time) {
+	for k, b := range m.buckets {
+		if now.Sub(b.updated) >= b.limit.Window {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,49 @@
+// Package ratelimit limits how many requests a client may make per window.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit parses "<requests>/<window>", e.g. "100/1m".
+func ParseLimit(s string) (Limit, error) {
+	n, w, ok := strings.Cut(strings.TrimSpace(s), "/")
+	requests, err := strconv.Atoi(n)
+	if !ok || err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want <requests>/<window>, e.g. 100/1m", s)
+	}
+	window, err := time.ParseDuration(w)
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want <requests>/<window>, e.g. 100/1m", s)
+	}
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Window.String()
+}
+
+// Result is the outcome of one Allow call.
+type Result struct {
+	Allowed   bool
+	Remaining int           // requests left in the current window or bucket
+	Reset     time.Duration // until the quota is fully restored
+	// RetryAfter is how long a denied client should wait before its next request.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests per key.
+type Limiter interface {
+	// Allow counts one request for key against l.
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/models"
+)
+
+// Audit trail
+//
+// Every create, update and delete of an order or item appends a
+// models.AuditEvent to the order's history. DynamoRepository writes the event
+// in the same transaction as the change, so the history neither misses a
+// committed write nor records a failed one. Events outlive their order, so
+// the history of a deleted order can still be read.
+//
+// The sort key is the event's time followed by its ID, and events are only
+// ever put if absent, so history is never overwritten. Events are ordered by
+// the clocks of the instances that wrote them; only the version of an order
+// or item orders its changes exactly.
+
+// newAuditEvent describes the change of an order (itemID == "") or item from
+// before to after; before is nil for creates and after for deletes.
+func newAuditEvent(ctx context.Context, op, orderID, itemID string, before, after any) (models.AuditEvent, error) {
+	changes, err := diff(before, after)
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+	e := models.AuditEvent{
+		OrderID:   orderID,
+		ID:        uuid.NewString(),
+		At:        time.Now().UTC().Format(models.TimeLayout),
+		Operation: op,
+		ItemID:    itemID,
+		Changes:   changes,
+	}
+	if p, ok := auth.FromContext(ctx); ok && p != nil {
+		e.Actor = p.Subject
+	}
+	return e, nil
+}
+
+// diff compares the JSON forms of before and after field by field.
+func diff(before, after any) ([]models.FieldChange, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(a))
+	for k := range b {
+		names[k] = struct{}{}
+	}
+	for k := range a {
+		names[k] = struct{}{}
+	}
+	changes := []models.FieldChange{}
+	for _, k := range sortedKeys(names) {
+		if !reflect.DeepEqual(b[k], a[k]) {
+			changes = append(changes, models.FieldChange{Field: k, Before: b[k], After: a[k]})
+		}
+	}
+	return changes, nil
+}
+
+func fields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(raw, &m)
+	return m, err
+}
+
+// auditPut builds the transaction operation that appends an audit event.
+func (r *DynamoRepository) auditPut(ctx context.Context, op, orderID, itemID string, before, after any) (types.TransactWriteItem, error) {
+	e, err := newAuditEvent(ctx, op, orderID, itemID, before, after)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	item, err := marshalScoped(ctx, e, eventTenantAttrs)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	item["at"] = &types.AttributeValueMemberS{Value: auditSortKey(e)}
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           &r.orderEventsTable,
+		Item:                item,
+		ConditionExpression: awsString("attribute_not_exists(order_id)"),
+	}}, nil
+}
+
+// auditSortKey returns the stored sort key of e, unique among its order's events.
+func auditSortKey(e models.AuditEvent) string {
+	return e.At + auditKeySeparator + e.ID
+}
+
+const auditKeySeparator = "#"
+
+// trimAuditSortKey restores At of events read back from the table. Events
+// written before IDs were added have a bare timestamp.
+func trimAuditSortKey(events []models.AuditEvent) {
+	for i := range events {
+		events[i].At, _, _ = strings.Cut(events[i].At, auditKeySeparator)
+	}
+}
+
+// ListOrderHistory returns a page of an order's audit events, oldest first.
+func (r *DynamoRepository) ListOrderHistory(ctx context.Context, orderID string, p PageRequest) (Page[models.AuditEvent], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.AuditEvent]{}, err
+	}
+	res, err := r.db.Query(ctx, &dynamodb.QueryInput{
+		TableName:              &r.orderEventsTable,
+		KeyConditionExpression: awsString("order_id = :oid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":oid": scopedS(ctx, orderID),
+		},
+		Limit:             pageLimit(p.Limit),
+		ExclusiveStartKey: start,
+	})
+	if err != nil {
+		return Page[models.AuditEvent]{}, mapErr(err, ErrConflict)
+	}
+	out := Page[models.AuditEvent]{}
+	if out.Items, err = unmarshalScopedList[models.AuditEvent](ctx, res.Items, eventTenantAttrs); err != nil {
+		return Page[models.AuditEvent]{}, err
+	}
+	trimAuditSortKey(out.Items)
+	if out.NextCursor, err = encodeCursor(res.LastEvaluatedKey); err != nil {
+		return Page[models.AuditEvent]{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+func TestAuditPutIsAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	stored, err := marshalScoped(ctx, testOrder("o1"), orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		if _, ok := in.(*dynamodb.GetItemInput); ok {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		}
+		return nil, nil
+	}}
+	r := NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", "")
+	for _, name := range []string{"bob", "carol"} {
+		o := testOrder("o1")
+		o.CustomerName = name
+		if err := r.UpdateOrder(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, in := range inputs[*dynamodb.TransactWriteItemsInput](fake) {
+		for _, op := range in.TransactItems {
+			if op.Put == nil || *op.Put.TableName != "order_events" {
+				continue
+			}
+			if op.Put.ConditionExpression == nil || *op.Put.ConditionExpression != "attribute_not_exists(order_id)" {
+				t.Errorf("audit put condition = %v, want attribute_not_exists(order_id)", op.Put.ConditionExpression)
+			}
+			at, id := stringAttr(op.Put.Item, "at"), stringAttr(op.Put.Item, "id")
+			if id == "" || !strings.HasSuffix(at, "#"+id) {
+				t.Errorf("audit sort key %q does not end in the event ID %q", at, id)
+			}
+			keys[at] = true
+		}
+	}
+	if len(keys) != 2 {
+		t.Errorf("got %d distinct audit sort keys, want 2", len(keys))
+	}
+}
+
+func TestListOrderHistoryTrimsSortKey(t *testing.T) {
+	ctx := context.Background()
+	event := func(at string) map[string]types.AttributeValue {
+		item, err := marshalScoped(ctx, models.AuditEvent{OrderID: "o1", At: at, Operation: models.AuditUpdate}, eventTenantAttrs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			event("2026-01-01T00:00:00.000000000Z"), // written before events had IDs
+			event("2026-01-01T00:00:01.000000000Z#0b6f7c1e-5d1a-4f59-9a53-2f7d3c1b8e42"),
+		}}, nil
+	}}
+	r := NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", "")
+	page, err := r.ListOrderHistory(ctx, "o1", PageRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].At != "2026-01-01T00:00:00.000000000Z" || page.Items[1].At != "2026-01-01T00:00:01.000000000Z" {
+		t.Errorf("history = %+v, want the bare timestamps", page.Items)
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest describes which page of a listing to fetch.
+// An empty Cursor starts from the beginning; Limit <= 0 lets the store decide.
+type PageRequest struct {
+	Limit  int32
+	Cursor string
+}
+
+// Page is a single page of results. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
+
+// encodeCursor turns a DynamoDB LastEvaluatedKey into an opaque, URL-safe token.
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	var m map[string]any
+	if err := attributevalue.UnmarshalMap(key, &m); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor reverses encodeCursor, returning ErrInvalidCursor on malformed input.
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil || len(m) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	key, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return key, nil
+}
+
+func pageLimit(limit int32) *int32 {
+	if limit <= 0 {
+		return nil
+	}
+	return &limit
+}
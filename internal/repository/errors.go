@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// Domain errors returned by every Repository implementation.
+// Errors caused by DynamoDB wrap both the sentinel and the original SDK error,
+// so errors.Is matches the sentinel while the cause stays available for logs.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrConflict        = errors.New("conflict")
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrInvalidTransition means the order lifecycle does not allow the requested status change.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrThrottled         = errors.New("throttled")
+	ErrUnavailable       = errors.New("storage unavailable")
+)
+
+// ErrPartialDelete is matched (via errors.Is) by a *PartialDeleteError.
+var ErrPartialDelete = errors.New("order partially deleted")
+
+// PartialDeleteError reports a cascading DeleteOrder that removed some, but not
+// all, of an order's items. The order record itself is left in place, so the
+// delete can be retried.
+type PartialDeleteError struct {
+	OrderID        string
+	DeletedItems   int
+	RemainingItems int
+	Err            error
+}
+
+func (e *PartialDeleteError) Error() string {
+	return fmt.Sprintf("order %s partially deleted (%d items deleted, %d remaining): %v",
+		e.OrderID, e.DeletedItems, e.RemainingItems, e.Err)
+}
+
+func (e *PartialDeleteError) Unwrap() []error { return []error{ErrPartialDelete, e.Err} }
+
+// mapErr translates AWS SDK errors into domain errors. A failed condition
+// maps to onCondition (e.g. ErrAlreadyExists for creates); callers that can
+// tell conditions apart more precisely do so before calling mapErr.
+func mapErr(err error, onCondition error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var (
+		ccf      *types.ConditionalCheckFailedException
+		canceled *types.TransactionCanceledException
+		txc      *types.TransactionConflictException
+		pte      *types.ProvisionedThroughputExceededException
+		rle      *types.RequestLimitExceeded
+		rnf      *types.ResourceNotFoundException
+		api      smithy.APIError
+		op       *smithy.OperationError
+	)
+	switch {
+	case errors.As(err, &ccf):
+		return fmt.Errorf("%w: %w", onCondition, err)
+	case errors.As(err, &canceled):
+		return fmt.Errorf("%w: %w", cancellationErr(canceled, []error{onCondition}), err)
+	case errors.As(err, &txc):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.As(err, &pte), errors.As(err, &rle):
+		return fmt.Errorf("%w: %w", ErrThrottled, err)
+	case errors.As(err, &rnf):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	case errors.As(err, &api):
+		switch {
+		case api.ErrorCode() == "ThrottlingException":
+			return fmt.Errorf("%w: %w", ErrThrottled, err)
+		case api.ErrorFault() == smithy.FaultServer:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	case errors.As(err, &op):
+		// No API error: the request never got a response (DNS, connection, retries exhausted).
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// mapTxErr is mapErr for TransactWriteItems: onCondition[i] is the domain
+// error for a failed condition on operation i (the last entry covers the rest).
+func mapTxErr(err error, onCondition ...error) error {
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		return fmt.Errorf("%w: %w", cancellationErr(canceled, onCondition), err)
+	}
+	return mapErr(err, ErrConflict)
+}
+
+// cancellationErr picks the domain error for a canceled transaction from its
+// per-operation cancellation reasons.
+func cancellationErr(e *types.TransactionCanceledException, onCondition []error) error {
+	for i, reason := range e.CancellationReasons {
+		if reason.Code == nil {
+			continue
+		}
+		switch *reason.Code {
+		case "ConditionalCheckFailed":
+			if len(onCondition) == 0 {
+				return ErrConflict
+			}
+			err := onCondition[min(i, len(onCondition)-1)]
+			if err == ErrNotFound && reason.Item != nil {
+				// The record exists, so another part of the condition failed.
+				return ErrConflict
+			}
+			return err
+		case "TransactionConflict":
+			return ErrConflict
+		case "ThrottlingError", "ProvisionedThroughputExceeded", "RequestLimitExceeded":
+			return ErrThrottled
+		}
+	}
+	return ErrConflict
+}
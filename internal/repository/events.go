@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/outbox"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// Domain events
+//
+// With an outbox configured, every change also writes the domain events that
+// describe it (see package outbox) next to its audit event, in the same
+// transaction. Item changes only produce item events, even though they also
+// move the order's totals.
+
+// orderEvents returns the domain events for a change of an order from before
+// to after; before is nil for creates and after for deletes. A PUT that
+// changes the status and other fields yields both OrderStatusChanged and
+// OrderUpdated.
+func orderEvents(ctx context.Context, id string, before, after *models.Order) ([]outbox.Event, error) {
+	var events []outbox.Event
+	add := func(typ string, data any) error {
+		e, err := newEvent(ctx, typ, id, "", data)
+		events = append(events, e)
+		return err
+	}
+	var err error
+	switch {
+	case before == nil && after == nil:
+	case before == nil:
+		err = add(outbox.OrderCreated, after)
+	case after == nil:
+		err = add(outbox.OrderDeleted, before)
+	default:
+		if before.Status != after.Status {
+			err = add(outbox.OrderStatusChanged, outbox.StatusChange{From: before.Status, To: after.Status, Order: *after})
+		}
+		// Anything but the status and the bookkeeping that changes on every write
+		b := *before
+		b.Status, b.UpdatedAt, b.Version = after.Status, after.UpdatedAt, after.Version
+		if err == nil && b != *after {
+			err = add(outbox.OrderUpdated, after)
+		}
+	}
+	return events, err
+}
+
+// itemEvents is orderEvents for items.
+func itemEvents(ctx context.Context, before, after *models.OrderItem) ([]outbox.Event, error) {
+	var (
+		typ string
+		it  = after
+	)
+	switch {
+	case before == nil:
+		typ = outbox.ItemAdded
+	case after == nil:
+		typ, it = outbox.ItemRemoved, before
+	default:
+		typ = outbox.ItemUpdated
+	}
+	e, err := newEvent(ctx, typ, it.OrderID, it.ID, it)
+	if err != nil {
+		return nil, err
+	}
+	return []outbox.Event{e}, nil
+}
+
+func newEvent(ctx context.Context, typ, orderID, itemID string, data any) (outbox.Event, error) {
+	e, err := outbox.NewEvent(typ, orderID, itemID, data)
+	e.TenantID = tenant.FromContext(ctx)
+	return e, err
+}
+
+// orderChange returns the operations recording a change of order id from
+// before to after: its audit event and, with an outbox, its domain events.
+func (r *DynamoRepository) orderChange(ctx context.Context, id string, before, after *models.Order) ([]types.TransactWriteItem, error) {
+	audit, err := r.auditPut(ctx, auditOp(before, after), id, "", value(before), value(after))
+	if err != nil {
+		return nil, err
+	}
+	if r.outboxTable == "" {
+		return []types.TransactWriteItem{audit}, nil
+	}
+	events, err := orderEvents(ctx, id, before, after)
+	if err != nil {
+		return nil, err
+	}
+	return r.withOutbox([]types.TransactWriteItem{audit}, events)
+}
+
+// itemChange is orderChange for items.
+func (r *DynamoRepository) itemChange(ctx context.Context, before, after *models.OrderItem) ([]types.TransactWriteItem, error) {
+	it := after
+	if it == nil {
+		it = before
+	}
+	audit, err := r.auditPut(ctx, auditOp(before, after), it.OrderID, it.ID, value(before), value(after))
+	if err != nil {
+		return nil, err
+	}
+	if r.outboxTable == "" {
+		return []types.TransactWriteItem{audit}, nil
+	}
+	events, err := itemEvents(ctx, before, after)
+	if err != nil {
+		return nil, err
+	}
+	return r.withOutbox([]types.TransactWriteItem{audit}, events)
+}
+
+func (r *DynamoRepository) withOutbox(ops []types.TransactWriteItem, events []outbox.Event) ([]types.TransactWriteItem, error) {
+	for _, e := range events {
+		op, err := outbox.Put(r.outboxTable, e)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// auditOp names the operation that turned before into after.
+func auditOp[T any](before, after *T) string {
+	switch {
+	case after == nil:
+		return models.AuditDelete
+	case before == nil:
+		return models.AuditCreate
+	}
+	return models.AuditUpdate
+}
+
+// value dereferences p for the audit diff, keeping nil as an untyped nil.
+func value[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
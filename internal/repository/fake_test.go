@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// fakeDynamo answers DynamoDB calls in process, before they are signed or
+// sent. It records every call's input, and respond returns its output; a nil
+// respond or output answers with an empty output.
+type fakeDynamo struct {
+	respond func(in any) (any, error)
+
+	mu    sync.Mutex
+	calls []any
+}
+
+func (f *fakeDynamo) client() *dynamodb.Client {
+	return dynamodb.New(dynamodb.Options{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		APIOptions: []func(*middleware.Stack) error{func(s *middleware.Stack) error {
+			return s.Initialize.Add(middleware.InitializeMiddlewareFunc("fakeDynamo",
+				func(ctx context.Context, in middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					out, err := f.call(in.Parameters)
+					return middleware.InitializeOutput{Result: out}, middleware.Metadata{}, err
+				}), middleware.Before)
+		}},
+	})
+}
+
+func (f *fakeDynamo) call(in any) (any, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, in)
+	f.mu.Unlock()
+	var (
+		out any
+		err error
+	)
+	if f.respond != nil {
+		out, err = f.respond(in)
+	}
+	if out == nil && err == nil {
+		out, err = emptyOutput(in)
+	}
+	return out, err
+}
+
+// inputs returns the recorded inputs of type T.
+func inputs[T any](f *fakeDynamo) []T {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []T
+	for _, c := range f.calls {
+		if in, ok := c.(T); ok {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+func emptyOutput(in any) (any, error) {
+	switch in.(type) {
+	case *dynamodb.GetItemInput:
+		return &dynamodb.GetItemOutput{}, nil
+	case *dynamodb.PutItemInput:
+		return &dynamodb.PutItemOutput{}, nil
+	case *dynamodb.UpdateItemInput:
+		return &dynamodb.UpdateItemOutput{}, nil
+	case *dynamodb.DeleteItemInput:
+		return &dynamodb.DeleteItemOutput{}, nil
+	case *dynamodb.QueryInput:
+		return &dynamodb.QueryOutput{}, nil
+	case *dynamodb.ScanInput:
+		return &dynamodb.ScanOutput{}, nil
+	case *dynamodb.TransactWriteItemsInput:
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	return nil, fmt.Errorf("fakeDynamo: unexpected %T", in)
+}
@@ -0,0 +1,513 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/outbox"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// MemoryRepository implements Repository in process memory.
+// It mirrors the DynamoDB implementation closely enough for tests and
+// offline local runs: it returns the same domain errors (ErrAlreadyExists on
+// duplicate IDs, ErrNotFound, ErrVersionMismatch), DeleteOrder cascades to
+// items, and items are returned ordered by their sort key (id). Every change
+// is recorded in the order's history and, with an outbox, as domain events.
+// Records are partitioned by the tenant in ctx, like DynamoRepository's key
+// prefixes.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	tenants map[string]*memoryTenant
+	outbox  *outbox.MemoryStore // nil disables domain events
+}
+
+// memoryTenant holds one tenant's records.
+type memoryTenant struct {
+	orders map[string]models.Order
+	items  map[string]map[string]models.OrderItem  // order_id -> id -> item
+	events map[string]map[string]models.AuditEvent // order_id -> auditSortKey -> event
+}
+
+// NewMemoryRepository returns an empty repository that adds domain events to
+// ob, or to no outbox when ob is nil.
+func NewMemoryRepository(ob *outbox.MemoryStore) *MemoryRepository {
+	return &MemoryRepository{tenants: map[string]*memoryTenant{}, outbox: ob}
+}
+
+// data returns the records of the tenant in ctx. Callers hold r.mu; unless
+// they hold it for writing, a tenant without records gets an unsaved empty set.
+func (r *MemoryRepository) data(ctx context.Context, create bool) *memoryTenant {
+	t := tenant.FromContext(ctx)
+	d, ok := r.tenants[t]
+	if !ok {
+		d = &memoryTenant{
+			orders: map[string]models.Order{},
+			items:  map[string]map[string]models.OrderItem{},
+			events: map[string]map[string]models.AuditEvent{},
+		}
+		if create {
+			r.tenants[t] = d
+		}
+	}
+	return d
+}
+
+// Orders
+func (r *MemoryRepository) CreateOrder(ctx context.Context, o *models.Order) error {
+	if o == nil {
+		return errors.New("order is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	if _, ok := d.orders[o.ID]; ok {
+		return ErrAlreadyExists
+	}
+	o.Version = 1
+	o.ItemCount = 0
+	o.Subtotal = models.Money{Currency: o.Currency()}
+	o.Total = o.Subtotal
+	if err := r.recordOrder(ctx, d, o.ID, nil, o); err != nil {
+		return err
+	}
+	d.orders[o.ID] = *o
+	return nil
+}
+
+func (r *MemoryRepository) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := r.data(ctx, false)
+	o, ok := d.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &o, nil
+}
+
+func (r *MemoryRepository) ListOrders(ctx context.Context) ([]models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := r.data(ctx, false)
+	var out []models.Order
+	for _, id := range sortedKeys(d.orders) {
+		out = append(out, d.orders[id])
+	}
+	return out, nil
+}
+
+// QueryOrders mirrors the DynamoDB index selection: with an owner, status or
+// customer_name filter orders are sorted by created_at, otherwise by id.
+func (r *MemoryRepository) QueryOrders(ctx context.Context, f OrderFilter, p PageRequest) (Page[models.Order], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.Order]{}, err
+	}
+	indexed := f.OwnerID != "" || f.Status != "" || f.CustomerName != ""
+	if !indexed && f.Sort != SortDefault {
+		return Page[models.Order]{}, ErrSortNeedsIndex
+	}
+	desc := f.Sort == SortCreatedDesc
+	sortKey := func(o models.Order) string {
+		if indexed {
+			return o.CreatedAt + "\x00" + o.ID
+		}
+		return o.ID
+	}
+
+	r.mu.RLock()
+	d := r.data(ctx, false)
+	var matched []models.Order
+	for _, o := range d.orders {
+		if f.OwnerID != "" && o.OwnerID != f.OwnerID ||
+			f.Status != "" && o.Status != f.Status ||
+			f.CustomerName != "" && o.CustomerName != f.CustomerName ||
+			f.CreatedFrom != "" && o.CreatedAt < f.CreatedFrom ||
+			f.CreatedTo != "" && o.CreatedAt > f.CreatedTo {
+			continue
+		}
+		matched = append(matched, o)
+	}
+	r.mu.RUnlock()
+	sort.Slice(matched, func(i, j int) bool {
+		if desc {
+			return sortKey(matched[i]) > sortKey(matched[j])
+		}
+		return sortKey(matched[i]) < sortKey(matched[j])
+	})
+
+	if start != nil {
+		after := stringAttr(start, "id")
+		if indexed {
+			after = stringAttr(start, "created_at") + "\x00" + after
+		}
+		matched = matched[sort.Search(len(matched), func(i int) bool {
+			if desc {
+				return sortKey(matched[i]) < after
+			}
+			return sortKey(matched[i]) > after
+		}):]
+	}
+	out := Page[models.Order]{Items: []models.Order{}}
+	if p.Limit <= 0 || int(p.Limit) >= len(matched) {
+		out.Items = append(out.Items, matched...)
+		return out, nil
+	}
+	out.Items = append(out.Items, matched[:p.Limit]...)
+	last := out.Items[len(out.Items)-1]
+	key := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: last.ID}}
+	if indexed {
+		key["created_at"] = &types.AttributeValueMemberS{Value: last.CreatedAt}
+	}
+	out.NextCursor, err = encodeCursor(key)
+	return out, err
+}
+
+func (r *MemoryRepository) UpdateOrder(ctx context.Context, o *models.Order) error {
+	if o == nil {
+		return errors.New("order is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	cur, ok := d.orders[o.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if cur.Version != o.Version {
+		return ErrVersionMismatch
+	}
+	if o.Status != cur.Status && !models.CanTransition(cur.Status, o.Status) {
+		return ErrInvalidTransition
+	}
+	o.Version++
+	if err := r.recordOrder(ctx, d, o.ID, &cur, o); err != nil {
+		o.Version--
+		return err
+	}
+	d.orders[o.ID] = *o
+	return nil
+}
+
+func (r *MemoryRepository) TransitionOrder(ctx context.Context, id, from, to, updatedAt string) (*models.Order, error) {
+	if !models.CanTransition(from, to) {
+		return nil, ErrInvalidTransition
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	o, ok := d.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if o.Status != from {
+		return nil, ErrConflict
+	}
+	cur := o
+	o.Status = to
+	o.UpdatedAt = updatedAt
+	o.Version++
+	if err := r.recordOrder(ctx, d, id, &cur, &o); err != nil {
+		return nil, err
+	}
+	d.orders[id] = o
+	return &o, nil
+}
+
+// DeleteOrder removes an order and its items, recording only the order's deletion.
+func (r *MemoryRepository) DeleteOrder(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	o, ok := d.orders[id]
+	if !ok && len(d.items[id]) == 0 {
+		return nil
+	}
+	var before *models.Order
+	if ok {
+		before = &o
+	}
+	if err := r.recordOrder(ctx, d, id, before, nil); err != nil {
+		return err
+	}
+	delete(d.items, id)
+	delete(d.orders, id)
+	return nil
+}
+
+// Order Items (PK: order_id, SK: id)
+func (r *MemoryRepository) CreateOrderItem(ctx context.Context, it *models.OrderItem) error {
+	if it == nil {
+		return errors.New("order item is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	items := d.items[it.OrderID]
+	if _, ok := items[it.ID]; ok {
+		return ErrAlreadyExists
+	}
+	if err := d.checkCurrency(it.OrderID, it.Price); err != nil {
+		return err
+	}
+	line, err := it.LineTotal()
+	if err != nil {
+		return err
+	}
+	if err := d.checkTotals(it.OrderID, line); err != nil {
+		return err
+	}
+	if items == nil {
+		items = map[string]models.OrderItem{}
+		d.items[it.OrderID] = items
+	}
+	it.Version = 1
+	if err := r.recordItem(ctx, d, nil, it); err != nil {
+		return err
+	}
+	items[it.ID] = *it
+	d.adjustTotals(it.OrderID, 1, line, it.UpdatedAt)
+	return nil
+}
+
+func (r *MemoryRepository) GetOrderItem(ctx context.Context, orderID, id string) (*models.OrderItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := r.data(ctx, false)
+	it, ok := d.items[orderID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &it, nil
+}
+
+func (r *MemoryRepository) ListOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := r.data(ctx, false)
+	items := d.items[orderID]
+	var out []models.OrderItem
+	for _, id := range sortedKeys(items) {
+		out = append(out, items[id])
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) ListOrderItemsPage(ctx context.Context, orderID string, p PageRequest) (Page[models.OrderItem], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.OrderItem]{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := r.data(ctx, false)
+	items := d.items[orderID]
+	ids, next := pageKeys(sortedKeys(items), stringAttr(start, "id"), p.Limit)
+	out := Page[models.OrderItem]{Items: make([]models.OrderItem, 0, len(ids))}
+	for _, id := range ids {
+		out.Items = append(out.Items, items[id])
+	}
+	if next != "" {
+		out.NextCursor, err = encodeCursor(map[string]types.AttributeValue{
+			"order_id": &types.AttributeValueMemberS{Value: orderID},
+			"id":       &types.AttributeValueMemberS{Value: next},
+		})
+	}
+	return out, err
+}
+
+func (r *MemoryRepository) UpdateOrderItem(ctx context.Context, it *models.OrderItem) error {
+	if it == nil {
+		return errors.New("order item is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	cur, ok := d.items[it.OrderID][it.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if cur.Version != it.Version {
+		return ErrVersionMismatch
+	}
+	if err := d.checkCurrency(it.OrderID, it.Price); err != nil {
+		return err
+	}
+	delta, err := lineDelta(cur, *it)
+	if err != nil {
+		return err
+	}
+	if err := d.checkTotals(it.OrderID, delta); err != nil {
+		return err
+	}
+	it.Version++
+	if err := r.recordItem(ctx, d, &cur, it); err != nil {
+		it.Version--
+		return err
+	}
+	d.items[it.OrderID][it.ID] = *it
+	if delta.Amount != 0 {
+		d.adjustTotals(it.OrderID, 0, delta, it.UpdatedAt)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteOrderItem(ctx context.Context, orderID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.data(ctx, true)
+	cur, ok := d.items[orderID][id]
+	if !ok {
+		return nil
+	}
+	if _, ok := d.orders[orderID]; !ok {
+		return ErrNotFound
+	}
+	line, err := cur.LineTotal()
+	if err != nil {
+		return err
+	}
+	if err := r.recordItem(ctx, d, &cur, nil); err != nil {
+		return err
+	}
+	delete(d.items[orderID], id)
+	d.adjustTotals(orderID, -1, line.Neg(), time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// recordOrder mirrors DynamoRepository.orderChange. It is called right
+// before the change is applied, which cannot fail afterwards; callers hold r.mu.
+func (r *MemoryRepository) recordOrder(ctx context.Context, d *memoryTenant, id string, before, after *models.Order) error {
+	var events []outbox.Event
+	if r.outbox != nil {
+		var err error
+		if events, err = orderEvents(ctx, id, before, after); err != nil {
+			return err
+		}
+	}
+	return r.record(ctx, d, id, "", auditOp(before, after), value(before), value(after), events)
+}
+
+// recordItem mirrors DynamoRepository.itemChange; callers hold r.mu.
+func (r *MemoryRepository) recordItem(ctx context.Context, d *memoryTenant, before, after *models.OrderItem) error {
+	it := after
+	if it == nil {
+		it = before
+	}
+	var events []outbox.Event
+	if r.outbox != nil {
+		var err error
+		if events, err = itemEvents(ctx, before, after); err != nil {
+			return err
+		}
+	}
+	return r.record(ctx, d, it.OrderID, it.ID, auditOp(before, after), value(before), value(after), events)
+}
+
+func (r *MemoryRepository) record(ctx context.Context, d *memoryTenant, orderID, itemID, op string, before, after any, events []outbox.Event) error {
+	e, err := newAuditEvent(ctx, op, orderID, itemID, before, after)
+	if err != nil {
+		return err
+	}
+	if d.events[orderID] == nil {
+		d.events[orderID] = map[string]models.AuditEvent{}
+	}
+	d.events[orderID][auditSortKey(e)] = e
+	if r.outbox != nil {
+		r.outbox.Add(events...)
+	}
+	return nil
+}
+
+// ListOrderHistory returns a page of an order's audit events, oldest first.
+func (r *MemoryRepository) ListOrderHistory(ctx context.Context, orderID string, p PageRequest) (Page[models.AuditEvent], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.AuditEvent]{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := r.data(ctx, false).events[orderID]
+	keys, next := pageKeys(sortedKeys(events), stringAttr(start, "at"), p.Limit)
+	out := Page[models.AuditEvent]{Items: make([]models.AuditEvent, 0, len(keys))}
+	for _, k := range keys {
+		out.Items = append(out.Items, events[k])
+	}
+	if next != "" {
+		out.NextCursor, err = encodeCursor(map[string]types.AttributeValue{
+			"order_id": &types.AttributeValueMemberS{Value: orderID},
+			"at":       &types.AttributeValueMemberS{Value: next},
+		})
+	}
+	return out, err
+}
+
+// checkCurrency mirrors the order currency condition in DynamoRepository.orderTotalsUpdate; callers hold r.mu.
+func (d *memoryTenant) checkCurrency(orderID string, m models.Money) error {
+	o, ok := d.orders[orderID]
+	if !ok {
+		return ErrNotFound
+	}
+	if o.Subtotal.Currency != m.Currency {
+		return ErrConflict
+	}
+	return nil
+}
+
+// checkTotals reports whether adding amount keeps the order's totals within
+// models.MaxAmount; callers hold r.mu.
+func (d *memoryTenant) checkTotals(orderID string, amount models.Money) error {
+	_, err := d.orders[orderID].Subtotal.Add(amount)
+	return err
+}
+
+// adjustTotals mirrors DynamoRepository.orderTotalsUpdate; callers check the
+// change with checkTotals first and hold r.mu.
+func (d *memoryTenant) adjustTotals(orderID string, items int, amount models.Money, updatedAt string) {
+	o := d.orders[orderID]
+	o.ItemCount += items
+	o.Subtotal.Amount += amount.Amount
+	o.Total.Amount += amount.Amount
+	o.Version++
+	o.UpdatedAt = updatedAt
+	d.orders[orderID] = o
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pageKeys returns up to limit keys strictly after `after`, plus the last returned
+// key when more remain (mirroring DynamoDB's LastEvaluatedKey).
+func pageKeys(keys []string, after string, limit int32) ([]string, string) {
+	if after != "" {
+		keys = keys[sort.SearchStrings(keys, after):]
+		if len(keys) > 0 && keys[0] == after {
+			keys = keys[1:]
+		}
+	}
+	if limit <= 0 || int(limit) >= len(keys) {
+		return keys, ""
+	}
+	keys = keys[:limit]
+	return keys, keys[len(keys)-1]
+}
+
+func stringAttr(key map[string]types.AttributeValue, name string) string {
+	if v, ok := key[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
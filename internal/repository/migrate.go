@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// MoneyMigrationStats counts the records rewritten by MigrateLegacyMoney, and
+// those it skipped because they changed while it ran.
+type MoneyMigrationStats struct {
+	Items     int
+	Orders    int
+	Conflicts int
+}
+
+// MigrateLegacyMoney rewrites records written before models.Money existed:
+// item prices stored as a float N become {amount, currency} maps, and orders
+// whose totals are missing or floats get them recomputed from their items.
+// Legacy values are read as models.DefaultCurrency. The migration is
+// idempotent and every write is conditioned on the record being unchanged
+// since it was read, so it is safe to run while the API is serving traffic.
+// A record that changed is skipped and counted in Conflicts; run the
+// migration again to pick it up.
+func (r *DynamoRepository) MigrateLegacyMoney(ctx context.Context) (MoneyMigrationStats, error) {
+	var stats MoneyMigrationStats
+
+	items := dynamodb.NewScanPaginator(r.db, &dynamodb.ScanInput{TableName: &r.orderItemsTable})
+	for items.HasMorePages() {
+		res, err := items.NextPage(ctx)
+		if err != nil {
+			return stats, mapErr(err, ErrConflict)
+		}
+		for _, raw := range res.Items {
+			if !models.IsLegacyMoney(raw["price"]) {
+				continue
+			}
+			ctx := tenantContext(ctx, raw)
+			var it models.OrderItem
+			if err := unmarshalScoped(ctx, raw, itemTenantAttrs, &it); err != nil {
+				return stats, err
+			}
+			price, err := it.Price.MarshalDynamoDBAttributeValue()
+			if err != nil {
+				return stats, err
+			}
+			_, err = r.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:           &r.orderItemsTable,
+				Key:                 itemKey(ctx, it.OrderID, it.ID),
+				UpdateExpression:    awsString("SET price = :price"),
+				ConditionExpression: awsString("price = :legacy"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":price":  price,
+					":legacy": raw["price"],
+				},
+			})
+			if err := mapErr(err, ErrConflict); errors.Is(err, ErrConflict) {
+				stats.Conflicts++
+				continue
+			} else if err != nil {
+				return stats, err
+			}
+			stats.Items++
+		}
+	}
+
+	orders := dynamodb.NewScanPaginator(r.db, &dynamodb.ScanInput{TableName: &r.ordersTable})
+	for orders.HasMorePages() {
+		res, err := orders.NextPage(ctx)
+		if err != nil {
+			return stats, mapErr(err, ErrConflict)
+		}
+		for _, raw := range res.Items {
+			if _, ok := raw["subtotal"].(*types.AttributeValueMemberM); ok {
+				continue
+			}
+			ctx := tenantContext(ctx, raw)
+			var o models.Order
+			if err := unmarshalScoped(ctx, raw, orderTenantAttrs, &o); err != nil {
+				return stats, err
+			}
+			if err := r.recomputeTotals(ctx, &o); errors.Is(err, ErrVersionMismatch) {
+				stats.Conflicts++
+				continue
+			} else if err != nil {
+				return stats, err
+			}
+			stats.Orders++
+		}
+	}
+	return stats, nil
+}
+
+// recomputeTotals rewrites an order's totals from its items, whatever they
+// were, e.g. missing or legacy floats. Like every order change it is audited
+// and published, and it fails with ErrVersionMismatch if the order changed
+// since it was read.
+func (r *DynamoRepository) recomputeTotals(ctx context.Context, o *models.Order) error {
+	_, err := r.repairTotals(ctx, o, true)
+	return err
+}
+
+// sumItems adds up the line totals of items in currency.
+func sumItems(items []models.OrderItem, currency string) (models.Money, error) {
+	sum := models.Money{Currency: currency}
+	for _, it := range items {
+		line, err := it.LineTotal()
+		if err != nil {
+			return models.Money{}, err
+		}
+		if sum, err = sum.Add(line); err != nil {
+			return models.Money{}, err
+		}
+	}
+	return sum, nil
+}
+
+// lineDelta returns the change in the order's totals when an item changes
+// from before to after. A currency change is ErrConflict.
+func lineDelta(before, after models.OrderItem) (models.Money, error) {
+	b, err := before.LineTotal()
+	if err != nil {
+		return models.Money{}, err
+	}
+	a, err := after.LineTotal()
+	if err != nil {
+		return models.Money{}, err
+	}
+	delta, err := a.Add(b.Neg())
+	if err != nil && !errors.Is(err, models.ErrAmountOutOfRange) {
+		return models.Money{}, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return delta, err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func legacyOrder(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id":            &types.AttributeValueMemberS{Value: id},
+		"customer_name": &types.AttributeValueMemberS{Value: "alice"},
+		"status":        &types.AttributeValueMemberS{Value: "NEW"},
+		"subtotal":      &types.AttributeValueMemberN{Value: "1.5"},
+		"total":         &types.AttributeValueMemberN{Value: "1.5"},
+		"version":       &types.AttributeValueMemberN{Value: "1"},
+	}
+}
+
+func TestMigrateLegacyMoneySkipsConflicts(t *testing.T) {
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		switch in := in.(type) {
+		case *dynamodb.ScanInput:
+			if *in.TableName == "order_items" {
+				return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{{
+					"order_id": &types.AttributeValueMemberS{Value: "o1"},
+					"id":       &types.AttributeValueMemberS{Value: "i1"},
+					"price":    &types.AttributeValueMemberN{Value: "1.5"},
+				}}}, nil
+			}
+			return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{legacyOrder("o1"), legacyOrder("o2")}}, nil
+		case *dynamodb.UpdateItemInput:
+			// The API rewrote the item's price since the scan
+			return nil, &types.ConditionalCheckFailedException{Message: aws.String("changed")}
+		case *dynamodb.TransactWriteItemsInput:
+			if stringAttr(in.TransactItems[0].Put.Item, "id") == "o1" {
+				return nil, &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+					{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")},
+				}}
+			}
+		}
+		return nil, nil
+	}}
+	r := NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", "")
+	stats, err := r.MigrateLegacyMoney(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (MoneyMigrationStats{Orders: 1, Conflicts: 2}) {
+		t.Errorf("stats = %+v, want 1 order migrated and 2 conflicts", stats)
+	}
+
+	txs := inputs[*dynamodb.TransactWriteItemsInput](fake)
+	if len(txs) != 2 {
+		t.Fatalf("got %d transactions, want one per order", len(txs))
+	}
+	audited := false
+	for _, op := range txs[1].TransactItems {
+		audited = audited || op.Put != nil && *op.Put.TableName == "order_events"
+	}
+	if !audited {
+		t.Error("recomputed totals were written without an audit event")
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+// Global secondary indexes on the orders table used by QueryOrders.
+// All project all attributes.
+const (
+	OwnerIndex    = "owner_id-created_at-index"      // PK: owner_id, SK: created_at (sparse)
+	StatusIndex   = "status-created_at-index"        // PK: status, SK: created_at
+	CustomerIndex = "customer_name-created_at-index" // PK: customer_name, SK: created_at
+)
+
+// ErrSortNeedsIndex is returned when sorting is requested but no filter selects an index.
+var ErrSortNeedsIndex = errors.New("sorting by created_at requires an owner_id, status or customer_name filter")
+
+// SortOrder selects the order of QueryOrders results.
+type SortOrder int
+
+const (
+	SortDefault     SortOrder = iota // index order when an index applies, otherwise table order
+	SortCreatedAsc                   // created_at ascending
+	SortCreatedDesc                  // created_at descending
+)
+
+// OrderFilter narrows QueryOrders; zero fields match everything.
+// CreatedFrom and CreatedTo are inclusive RFC 3339 UTC timestamps.
+type OrderFilter struct {
+	OwnerID      string
+	Status       string
+	CustomerName string
+	CreatedFrom  string
+	CreatedTo    string
+	Sort         SortOrder
+}
+
+// QueryOrders returns a page of orders matching f. An owner filter queries
+// OwnerIndex, otherwise a status filter queries StatusIndex, otherwise a
+// customer_name filter queries CustomerIndex; only when none is set does it
+// fall back to a filtered Scan, which cannot sort.
+// Remaining conditions are applied as a FilterExpression, so a page may hold
+// fewer than p.Limit orders even when NextCursor is set.
+func (r *DynamoRepository) QueryOrders(ctx context.Context, f OrderFilter, p PageRequest) (Page[models.Order], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.Order]{}, err
+	}
+
+	var (
+		index, pkAttr, pkValue string
+		filters                []string
+		names                  = map[string]string{}
+		values                 = map[string]types.AttributeValue{}
+	)
+	switch {
+	case f.OwnerID != "":
+		index, pkAttr, pkValue = OwnerIndex, "owner_id", f.OwnerID
+	case f.Status != "":
+		index, pkAttr, pkValue = StatusIndex, "status", f.Status
+	case f.CustomerName != "":
+		index, pkAttr, pkValue = CustomerIndex, "customer_name", f.CustomerName
+	case f.Sort != SortDefault:
+		return Page[models.Order]{}, ErrSortNeedsIndex
+	}
+	if f.Status != "" && pkAttr != "status" {
+		filters = append(filters, "#status = :status")
+		names["#status"] = "status"
+		values[":status"] = scopedS(ctx, f.Status)
+	}
+	if f.CustomerName != "" && pkAttr != "customer_name" {
+		filters = append(filters, "customer_name = :customer")
+		values[":customer"] = scopedS(ctx, f.CustomerName)
+	}
+
+	if index != "" {
+		pkValue = scoped(ctx, pkValue)
+	}
+	// A cursor from a different filter would make DynamoDB reject the request.
+	if start != nil && (index == "" && len(start) != 1 || index != "" && stringAttr(start, pkAttr) != pkValue) {
+		return Page[models.Order]{}, ErrInvalidCursor
+	}
+
+	created := createdCondition(f, values)
+	var res struct {
+		items []map[string]types.AttributeValue
+		last  map[string]types.AttributeValue
+	}
+	if index != "" {
+		names["#pk"] = pkAttr
+		values[":pk"] = &types.AttributeValueMemberS{Value: pkValue}
+		keyCond := "#pk = :pk"
+		if created != "" {
+			keyCond += " AND " + created
+		}
+		out, err := r.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 &r.ordersTable,
+			IndexName:                 &index,
+			KeyConditionExpression:    &keyCond,
+			FilterExpression:          joinFilters(filters),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+			ScanIndexForward:          awsBool(f.Sort != SortCreatedDesc),
+			Limit:                     pageLimit(p.Limit),
+			ExclusiveStartKey:         start,
+		})
+		if err != nil {
+			return Page[models.Order]{}, mapErr(err, ErrConflict)
+		}
+		res.items, res.last = out.Items, out.LastEvaluatedKey
+	} else {
+		filters = append(filters, tenantFilter(ctx, values))
+		if created != "" {
+			filters = append(filters, created)
+		}
+		in := &dynamodb.ScanInput{
+			TableName:         &r.ordersTable,
+			FilterExpression:  joinFilters(filters),
+			Limit:             pageLimit(p.Limit),
+			ExclusiveStartKey: start,
+		}
+		if len(values) > 0 {
+			in.ExpressionAttributeValues = values
+		}
+		out, err := r.db.Scan(ctx, in)
+		if err != nil {
+			return Page[models.Order]{}, mapErr(err, ErrConflict)
+		}
+		res.items, res.last = out.Items, out.LastEvaluatedKey
+	}
+
+	page := Page[models.Order]{}
+	if page.Items, err = unmarshalScopedList[models.Order](ctx, res.items, orderTenantAttrs); err != nil {
+		return Page[models.Order]{}, err
+	}
+	if page.NextCursor, err = encodeCursor(res.last); err != nil {
+		return Page[models.Order]{}, err
+	}
+	return page, nil
+}
+
+// createdCondition builds the created_at range condition, adding its values.
+func createdCondition(f OrderFilter, values map[string]types.AttributeValue) string {
+	switch {
+	case f.CreatedFrom != "" && f.CreatedTo != "":
+		values[":from"] = &types.AttributeValueMemberS{Value: f.CreatedFrom}
+		values[":to"] = &types.AttributeValueMemberS{Value: f.CreatedTo}
+		return "created_at BETWEEN :from AND :to"
+	case f.CreatedFrom != "":
+		values[":from"] = &types.AttributeValueMemberS{Value: f.CreatedFrom}
+		return "created_at >= :from"
+	case f.CreatedTo != "":
+		values[":to"] = &types.AttributeValueMemberS{Value: f.CreatedTo}
+		return "created_at <= :to"
+	}
+	return ""
+}
+
+func joinFilters(filters []string) *string {
+	if len(filters) == 0 {
+		return nil
+	}
+	return awsString(strings.Join(filters, " AND "))
+}
+
+func awsBool(b bool) *bool { return &b }
@@ -3,41 +3,67 @@ package repository
 import (
 	"context"
 	"errors"
-	"sync"
+	"strconv"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"go-serverless-api-terraform/internal/models"
 )
 
+// maxTransactItems is DynamoDB's limit on operations in a single TransactWriteItems call.
+const maxTransactItems = 100
+
 // Repository defines CRUD operations for orders and order items.
+//
+// Get methods return ErrNotFound for missing records, and creates return
+// ErrAlreadyExists for duplicate IDs (see errors.go for the full set).
+// Create methods set Version to 1. Update methods treat the Version on the
+// passed model as the expected stored version, write Version+1, and return
+// ErrVersionMismatch when another writer got there first. Every change is
+// recorded in the order's history (see audit.go).
 type Repository interface {
 	// Orders
 	CreateOrder(ctx context.Context, o *models.Order) error
 	GetOrder(ctx context.Context, id string) (*models.Order, error)
 	ListOrders(ctx context.Context) ([]models.Order, error)
+	QueryOrders(ctx context.Context, f OrderFilter, p PageRequest) (Page[models.Order], error)
 	UpdateOrder(ctx context.Context, o *models.Order) error
+	TransitionOrder(ctx context.Context, id, from, to, updatedAt string) (*models.Order, error)
 	DeleteOrder(ctx context.Context, id string) error
 
 	// Order items
 	CreateOrderItem(ctx context.Context, it *models.OrderItem) error
 	GetOrderItem(ctx context.Context, orderID, id string) (*models.OrderItem, error)
 	ListOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error)
+	ListOrderItemsPage(ctx context.Context, orderID string, p PageRequest) (Page[models.OrderItem], error)
 	UpdateOrderItem(ctx context.Context, it *models.OrderItem) error
 	DeleteOrderItem(ctx context.Context, orderID, id string) error
+
+	// History
+	ListOrderHistory(ctx context.Context, orderID string, p PageRequest) (Page[models.AuditEvent], error)
 }
 
 // DynamoRepository implements Repository using AWS DynamoDB.
 type DynamoRepository struct {
-	db              *dynamodb.Client
-	ordersTable     string
-	orderItemsTable string
+	db               *dynamodb.Client
+	ordersTable      string
+	orderItemsTable  string
+	orderEventsTable string
+	outboxTable      string // empty disables domain events
 }
 
-func NewDynamoRepository(db *dynamodb.Client, ordersTable, orderItemsTable string) *DynamoRepository {
-	return &DynamoRepository{db: db, ordersTable: ordersTable, orderItemsTable: orderItemsTable}
+// NewDynamoRepository returns a repository on the given tables. An empty
+// outboxTable disables the outbox of domain events.
+func NewDynamoRepository(db *dynamodb.Client, ordersTable, orderItemsTable, orderEventsTable, outboxTable string) *DynamoRepository {
+	return &DynamoRepository{
+		db:               db,
+		ordersTable:      ordersTable,
+		orderItemsTable:  orderItemsTable,
+		orderEventsTable: orderEventsTable,
+		outboxTable:      outboxTable,
+	}
 }
 
 // Orders
@@ -45,174 +71,600 @@ func (r *DynamoRepository) CreateOrder(ctx context.Context, o *models.Order) err
 	if o == nil {
 		return errors.New("order is nil")
 	}
-	item, err := attributevalue.MarshalMap(o)
+	o.Version = 1
+	o.ItemCount = 0
+	o.Subtotal = models.Money{Currency: o.Currency()}
+	o.Total = o.Subtotal
+	item, err := marshalScoped(ctx, o, orderTenantAttrs)
+	if err != nil {
+		return err
+	}
+	changes, err := r.orderChange(ctx, o.ID, nil, o)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+	ops := append([]types.TransactWriteItem{{Put: &types.Put{
 		TableName:           &r.ordersTable,
 		Item:                item,
 		ConditionExpression: awsString("attribute_not_exists(id)"),
-	})
-	return err
+	}}}, changes...)
+	_, err = r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ops})
+	return mapTxErr(err, ErrAlreadyExists)
 }
 
 func (r *DynamoRepository) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	return r.getOrder(ctx, id, false)
+}
+
+func (r *DynamoRepository) getOrder(ctx context.Context, id string, consistent bool) (*models.Order, error) {
 	res, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: &r.ordersTable,
-		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		TableName:      &r.ordersTable,
+		Key:            orderKey(ctx, id),
+		ConsistentRead: &consistent,
 	})
 	if err != nil {
-		return nil, err
+		return nil, mapErr(err, ErrConflict)
 	}
 	if res.Item == nil {
-		return nil, nil
+		return nil, ErrNotFound
 	}
 	var o models.Order
-	if err := attributevalue.UnmarshalMap(res.Item, &o); err != nil {
+	if err := unmarshalScoped(ctx, res.Item, orderTenantAttrs, &o); err != nil {
 		return nil, err
 	}
 	return &o, nil
 }
 
+// ListOrders returns every order, following LastEvaluatedKey across all scan pages.
 func (r *DynamoRepository) ListOrders(ctx context.Context) ([]models.Order, error) {
-	res, err := r.db.Scan(ctx, &dynamodb.ScanInput{TableName: &r.ordersTable})
-	if err != nil {
-		return nil, err
-	}
 	var out []models.Order
-	if err := attributevalue.UnmarshalListOfMaps(res.Items, &out); err != nil {
-		return nil, err
+	values := map[string]types.AttributeValue{}
+	filter := tenantFilter(ctx, values)
+	in := &dynamodb.ScanInput{TableName: &r.ordersTable, FilterExpression: &filter}
+	if len(values) > 0 {
+		in.ExpressionAttributeValues = values
+	}
+	p := dynamodb.NewScanPaginator(r.db, in)
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, mapErr(err, ErrConflict)
+		}
+		page, err := unmarshalScopedList[models.Order](ctx, res.Items, orderTenantAttrs)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
 	}
 	return out, nil
 }
 
+// UpdateOrder writes the order and its events in one transaction. A status
+// change the lifecycle does not allow fails with ErrInvalidTransition.
 func (r *DynamoRepository) UpdateOrder(ctx context.Context, o *models.Order) error {
 	if o == nil {
 		return errors.New("order is nil")
 	}
-	item, err := attributevalue.MarshalMap(o)
+	// The stored order is needed to describe the change; the version condition
+	// below guarantees it is still current when the transaction commits.
+	cur, err := r.getOrder(ctx, o.ID, true)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{TableName: &r.ordersTable, Item: item})
-	return err
+	if cur.Version != o.Version {
+		return ErrVersionMismatch
+	}
+	if o.Status != cur.Status && !models.CanTransition(cur.Status, o.Status) {
+		return ErrInvalidTransition
+	}
+	expected := o.Version
+	o.Version++
+	ops, err := r.orderPut(ctx, cur, o, expected)
+	if err != nil {
+		o.Version = expected
+		return err
+	}
+	if _, err := r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ops}); err != nil {
+		o.Version = expected
+		return mapTxErr(err, ErrVersionMismatch)
+	}
+	return nil
+}
+
+// orderPut builds the version-conditioned write of o over cur and its events.
+func (r *DynamoRepository) orderPut(ctx context.Context, cur, o *models.Order, expected int64) ([]types.TransactWriteItem, error) {
+	item, err := marshalScoped(ctx, o, orderTenantAttrs)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := r.orderChange(ctx, o.ID, cur, o)
+	if err != nil {
+		return nil, err
+	}
+	cond, values := versionCondition("id", expected)
+	return append([]types.TransactWriteItem{{Put: &types.Put{
+		TableName:                 &r.ordersTable,
+		Item:                      item,
+		ConditionExpression:       cond,
+		ExpressionAttributeValues: values,
+	}}}, changes...), nil
+}
+
+// TransitionOrder moves an order from one status to another, failing with
+// ErrInvalidTransition if the lifecycle does not allow it and ErrConflict if
+// the stored status is no longer from or the order changes concurrently.
+func (r *DynamoRepository) TransitionOrder(ctx context.Context, id, from, to, updatedAt string) (*models.Order, error) {
+	if !models.CanTransition(from, to) {
+		return nil, ErrInvalidTransition
+	}
+	cur, err := r.getOrder(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if cur.Status != from {
+		return nil, ErrConflict
+	}
+	o := *cur
+	o.Status = to
+	o.UpdatedAt = updatedAt
+	o.Version++
+	ops, err := r.orderPut(ctx, cur, &o, cur.Version)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ops}); err != nil {
+		return nil, mapTxErr(err, ErrConflict)
+	}
+	return &o, nil
 }
 
+// DeleteOrder removes an order and all of its items using TransactWriteItems.
+//
+// Item deletes are grouped into transactions of at most maxTransactItems
+// operations. Every transaction but the last also decrements the order's
+// totals by the items it removes; the last one deletes the order itself and
+// records its events. Orders whose items fit in that last transaction are
+// therefore deleted atomically. Larger orders are deleted chunk by chunk; if a chunk fails, the
+// order record is still present with totals matching its remaining items, and
+// a *PartialDeleteError reports how far the cascade got, so retrying the
+// delete completes it.
+//
+// The order delete is conditioned on the version read at the start, which
+// every item change bumps, so an item added during the cascade cancels it
+// instead of being orphaned. The cascade then starts over, and after a few
+// attempts DeleteOrder gives up with ErrConflict.
+//
+// Only the deletion of the order is recorded in its history and outbox; the
+// items removed with it get no events of their own.
 func (r *DynamoRepository) DeleteOrder(ctx context.Context, id string) error {
-	// delete order items first in parallel with bounded concurrency
-	items, err := r.ListOrderItems(ctx, id)
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = r.deleteOrder(ctx, id); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *DynamoRepository) deleteOrder(ctx context.Context, id string) error {
+	order, err := r.getOrder(ctx, id, true)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	items, err := r.listOrderItems(ctx, id, true)
+	if err != nil {
+		return err
+	}
+	if order == nil && len(items) == 0 {
+		return nil
+	}
+	total := len(items)
+	deleted := 0
+	fail := func(err error) error {
+		if deleted == 0 {
+			return err
+		}
+		return &PartialDeleteError{OrderID: id, DeletedItems: deleted, RemainingItems: total - deleted, Err: err}
+	}
+	changes, err := r.orderChange(ctx, id, order, nil)
 	if err != nil {
 		return err
 	}
-	sem := make(chan struct{}, 8) // concurrency limit
-	var wg sync.WaitGroup
-	var firstErr error
-	var mu sync.Mutex
+	del := &types.Delete{
+		TableName:           &r.ordersTable,
+		Key:                 orderKey(ctx, id),
+		ConditionExpression: awsString("attribute_not_exists(id)"),
+	}
+	if order != nil {
+		del.ConditionExpression, del.ExpressionAttributeValues = versionCondition("id", order.Version)
+	}
+	final := append([]types.TransactWriteItem{{Delete: del}}, changes...)
+	for len(items)+len(final) > maxTransactItems {
+		chunk := items[:maxTransactItems-1]
+		ops := r.itemDeletes(ctx, chunk)
+		amount, err := sumItems(chunk, "")
+		if err != nil {
+			return fail(err)
+		}
+		amount = amount.Neg()
+		ops = append(ops, r.orderTotalsUpdate(ctx, id, -len(chunk), amount, ""))
+		if err := r.transactTotals(ctx, ops, amount.Currency); err != nil {
+			return fail(mapTxErr(err, ErrConflict))
+		}
+		deleted += len(chunk)
+		items = items[len(chunk):]
+		if order != nil {
+			// orderTotalsUpdate bumped the version
+			order.Version++
+			del.ConditionExpression, del.ExpressionAttributeValues = versionCondition("id", order.Version)
+		}
+	}
+	ops := append(r.itemDeletes(ctx, items), final...)
+	if _, err := r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ops}); err != nil {
+		return fail(mapTxErr(err, ErrConflict))
+	}
+	return nil
+}
+
+// itemDeletes builds version-conditioned deletes so a concurrently modified
+// item cancels the transaction instead of skewing the order totals.
+func (r *DynamoRepository) itemDeletes(ctx context.Context, items []models.OrderItem) []types.TransactWriteItem {
+	ops := make([]types.TransactWriteItem, 0, len(items)+1)
 	for _, it := range items {
-		wg.Add(1)
-		it := it
-		go func() {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-			if err := r.DeleteOrderItem(ctx, id, it.ID); err != nil {
-				mu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				mu.Unlock()
-			}
-		}()
-	}
-	wg.Wait()
-	if firstErr != nil {
-		return firstErr
-	}
-	_, err = r.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &r.ordersTable,
-		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
-	})
+		cond, values := versionCondition("id", it.Version)
+		ops = append(ops, types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                 &r.orderItemsTable,
+			Key:                       itemKey(ctx, it.OrderID, it.ID),
+			ConditionExpression:       cond,
+			ExpressionAttributeValues: values,
+		}})
+	}
+	return ops
+}
+
+// orderTotalsUpdate adds items and amount to an order's totals and bumps its
+// version, so a concurrent full-order PUT cannot overwrite the new totals.
+// The update only applies if amount is in the order's currency and the new
+// subtotal stays within models.MaxAmount. Totals are nested maps, which ADD
+// cannot reach, so they are updated with SET arithmetic; orders whose totals
+// predate models.Money fail the condition, and transactTotals migrates them.
+// An empty updatedAt leaves updated_at untouched.
+func (r *DynamoRepository) orderTotalsUpdate(ctx context.Context, orderID string, items int, amount models.Money, updatedAt string) types.TransactWriteItem {
+	set := "SET subtotal.amount = subtotal.amount + :amount, #total.amount = #total.amount + :amount"
+	values := map[string]types.AttributeValue{
+		":n":        &types.AttributeValueMemberN{Value: strconv.Itoa(items)},
+		":amount":   &types.AttributeValueMemberN{Value: strconv.FormatInt(amount.Amount, 10)},
+		":currency": &types.AttributeValueMemberS{Value: amount.Currency},
+		":one":      &types.AttributeValueMemberN{Value: "1"},
+		":lo":       &types.AttributeValueMemberN{Value: strconv.FormatInt(-models.MaxAmount-amount.Amount, 10)},
+		":hi":       &types.AttributeValueMemberN{Value: strconv.FormatInt(models.MaxAmount-amount.Amount, 10)},
+	}
+	if updatedAt != "" {
+		set += ", updated_at = :now"
+		values[":now"] = &types.AttributeValueMemberS{Value: updatedAt}
+	}
+	return types.TransactWriteItem{Update: &types.Update{
+		TableName:                 &r.ordersTable,
+		Key:                       orderKey(ctx, orderID),
+		UpdateExpression:          awsString(set + " ADD item_count :n, version :one"),
+		ConditionExpression:       awsString("attribute_exists(id) AND subtotal.currency = :currency AND subtotal.amount BETWEEN :lo AND :hi"),
+		ExpressionAttributeNames:  map[string]string{"#total": "total"},
+		ExpressionAttributeValues: values,
+		// ALL_OLD lets cancellationErr tell a missing order from a currency mismatch
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}}
+}
+
+// transactTotals runs a transaction containing an orderTotalsUpdate of an
+// amount in currency. If the update failed because the order's totals are
+// missing or legacy floats, so the SET arithmetic cannot apply, it recomputes
+// them from the items (as migrate-money would) and runs the transaction
+// again. If the totals would leave models.MaxAmount, it returns
+// models.ErrAmountOutOfRange. Other errors are returned unmapped, for mapTxErr.
+func (r *DynamoRepository) transactTotals(ctx context.Context, ops []types.TransactWriteItem, currency string) error {
+	in := &dynamodb.TransactWriteItemsInput{TransactItems: ops}
+	_, err := r.db.TransactWriteItems(ctx, in)
+	item, ok := totalsCheckFailed(err)
+	if !ok {
+		return err
+	}
+	if subtotal, ok := item["subtotal"].(*types.AttributeValueMemberM); ok {
+		if c, ok := subtotal.Value["currency"].(*types.AttributeValueMemberS); ok && c.Value == currency {
+			// The order exists in the right currency, so the range check failed.
+			return models.ErrAmountOutOfRange
+		}
+		return err
+	}
+	var o models.Order
+	if err := unmarshalScoped(ctx, item, orderTenantAttrs, &o); err != nil {
+		return err
+	}
+	if err := r.recomputeTotals(ctx, &o); err != nil && !errors.Is(err, ErrVersionMismatch) {
+		return err
+	}
+	_, err = r.db.TransactWriteItems(ctx, in)
 	return err
 }
 
+// totalsCheckFailed returns the stored order of a canceled transaction whose
+// orderTotalsUpdate failed its condition. It relies on that update being the
+// only operation returning ALL_OLD.
+func totalsCheckFailed(err error) (map[string]types.AttributeValue, bool) {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return nil, false
+	}
+	for _, reason := range canceled.CancellationReasons {
+		if reason.Code != nil && *reason.Code == "ConditionalCheckFailed" && reason.Item != nil {
+			return reason.Item, true
+		}
+	}
+	return nil, false
+}
+
+// RepairTotals recomputes an order's item_count, subtotal and total from its
+// items and, if they drifted, stores them along with the order's events. It
+// reports whether the order was changed. A missing order is not an error,
+// and ErrVersionMismatch means the order changed while it was being checked.
+func (r *DynamoRepository) RepairTotals(ctx context.Context, orderID string) (bool, error) {
+	// Read the order first: an item change after this read also bumps the
+	// order's version, so the conditioned write below cannot use stale items.
+	cur, err := r.getOrder(ctx, orderID, true)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return r.repairTotals(ctx, cur, false)
+}
+
+// repairTotals stores cur's totals summed from its items through orderPut,
+// with the order's events, conditioned on cur's version. Unless force is set
+// it leaves totals that are already right alone.
+func (r *DynamoRepository) repairTotals(ctx context.Context, cur *models.Order, force bool) (bool, error) {
+	items, err := r.listOrderItems(ctx, cur.ID, true)
+	if err != nil {
+		return false, err
+	}
+	subtotal, err := sumItems(items, cur.Currency())
+	if err != nil {
+		return false, err
+	}
+	if !force && cur.ItemCount == len(items) && cur.Subtotal == subtotal && cur.Total == subtotal {
+		return false, nil
+	}
+	o := *cur
+	o.ItemCount, o.Subtotal, o.Total = len(items), subtotal, subtotal
+	o.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	o.Version++
+	ops, err := r.orderPut(ctx, cur, &o, cur.Version)
+	if err != nil {
+		return false, err
+	}
+	if _, err := r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ops}); err != nil {
+		return false, mapTxErr(err, ErrVersionMismatch)
+	}
+	return true, nil
+}
+
 // Order Items (PK: order_id, SK: id)
+
+// CreateOrderItem stores a new item and adds it to the order's totals in one
+// transaction. It fails with ErrNotFound if the order does not exist.
 func (r *DynamoRepository) CreateOrderItem(ctx context.Context, it *models.OrderItem) error {
 	if it == nil {
 		return errors.New("order item is nil")
 	}
-	item, err := attributevalue.MarshalMap(it)
+	line, err := it.LineTotal()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           &r.orderItemsTable,
-		Item:                item,
-		ConditionExpression: awsString("attribute_not_exists(order_id) AND attribute_not_exists(id)"),
-	})
-	return err
+	it.Version = 1
+	item, err := marshalScoped(ctx, it, itemTenantAttrs)
+	if err != nil {
+		return err
+	}
+	changes, err := r.itemChange(ctx, nil, it)
+	if err != nil {
+		return err
+	}
+	ops := append([]types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName:           &r.orderItemsTable,
+			Item:                item,
+			ConditionExpression: awsString("attribute_not_exists(order_id) AND attribute_not_exists(id)"),
+		}},
+		r.orderTotalsUpdate(ctx, it.OrderID, 1, line, it.UpdatedAt),
+	}, changes...)
+	return mapTxErr(r.transactTotals(ctx, ops, line.Currency), ErrAlreadyExists, ErrNotFound)
 }
 
 func (r *DynamoRepository) GetOrderItem(ctx context.Context, orderID, id string) (*models.OrderItem, error) {
+	return r.getOrderItem(ctx, orderID, id, false)
+}
+
+func (r *DynamoRepository) getOrderItem(ctx context.Context, orderID, id string, consistent bool) (*models.OrderItem, error) {
 	res, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: &r.orderItemsTable,
-		Key: map[string]types.AttributeValue{
-			"order_id": &types.AttributeValueMemberS{Value: orderID},
-			"id":       &types.AttributeValueMemberS{Value: id},
-		},
+		TableName:      &r.orderItemsTable,
+		Key:            itemKey(ctx, orderID, id),
+		ConsistentRead: &consistent,
 	})
 	if err != nil {
-		return nil, err
+		return nil, mapErr(err, ErrConflict)
 	}
 	if res.Item == nil {
-		return nil, nil
+		return nil, ErrNotFound
 	}
 	var it models.OrderItem
-	if err := attributevalue.UnmarshalMap(res.Item, &it); err != nil {
+	if err := unmarshalScoped(ctx, res.Item, itemTenantAttrs, &it); err != nil {
 		return nil, err
 	}
 	return &it, nil
 }
 
+// ListOrderItems returns every item of an order, following LastEvaluatedKey across all query pages.
 func (r *DynamoRepository) ListOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error) {
-	res, err := r.db.Query(ctx, &dynamodb.QueryInput{
+	return r.listOrderItems(ctx, orderID, false)
+}
+
+// listOrderItems is ListOrderItems; writes derived from the items, such as
+// totals, read them consistently so they reflect every committed change.
+func (r *DynamoRepository) listOrderItems(ctx context.Context, orderID string, consistent bool) ([]models.OrderItem, error) {
+	var out []models.OrderItem
+	in := r.orderItemsQuery(ctx, orderID)
+	in.ConsistentRead = &consistent
+	p := dynamodb.NewQueryPaginator(r.db, in)
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, mapErr(err, ErrConflict)
+		}
+		page, err := unmarshalScopedList[models.OrderItem](ctx, res.Items, itemTenantAttrs)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+	}
+	return out, nil
+}
+
+// ListOrderItemsPage returns a single query page of an order's items starting at p.Cursor.
+func (r *DynamoRepository) ListOrderItemsPage(ctx context.Context, orderID string, p PageRequest) (Page[models.OrderItem], error) {
+	start, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return Page[models.OrderItem]{}, err
+	}
+	in := r.orderItemsQuery(ctx, orderID)
+	in.Limit = pageLimit(p.Limit)
+	in.ExclusiveStartKey = start
+	res, err := r.db.Query(ctx, in)
+	if err != nil {
+		return Page[models.OrderItem]{}, mapErr(err, ErrConflict)
+	}
+	out := Page[models.OrderItem]{}
+	if out.Items, err = unmarshalScopedList[models.OrderItem](ctx, res.Items, itemTenantAttrs); err != nil {
+		return Page[models.OrderItem]{}, err
+	}
+	if out.NextCursor, err = encodeCursor(res.LastEvaluatedKey); err != nil {
+		return Page[models.OrderItem]{}, err
+	}
+	return out, nil
+}
+
+func (r *DynamoRepository) orderItemsQuery(ctx context.Context, orderID string) *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
 		TableName:              &r.orderItemsTable,
 		KeyConditionExpression: awsString("order_id = :oid"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":oid": &types.AttributeValueMemberS{Value: orderID},
+			":oid": scopedS(ctx, orderID),
 		},
-	})
-	if err != nil {
-		return nil, err
 	}
-	var out []models.OrderItem
-	if err := attributevalue.UnmarshalListOfMaps(res.Items, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
 }
 
+// UpdateOrderItem writes the item and applies the change in its line total
+// to the order's totals in one transaction.
 func (r *DynamoRepository) UpdateOrderItem(ctx context.Context, it *models.OrderItem) error {
 	if it == nil {
 		return errors.New("order item is nil")
 	}
-	item, err := attributevalue.MarshalMap(it)
+	// The stored line total is needed for the delta; the version condition
+	// below guarantees it is still current when the transaction commits.
+	cur, err := r.getOrderItem(ctx, it.OrderID, it.ID, true)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{TableName: &r.orderItemsTable, Item: item})
-	return err
+	if cur.Version != it.Version {
+		return ErrVersionMismatch
+	}
+	expected := it.Version
+	it.Version++
+	item, err := marshalScoped(ctx, it, itemTenantAttrs)
+	if err != nil {
+		it.Version = expected
+		return err
+	}
+	cond, values := versionCondition("id", expected)
+	ops := []types.TransactWriteItem{{Put: &types.Put{
+		TableName:                 &r.orderItemsTable,
+		Item:                      item,
+		ConditionExpression:       cond,
+		ExpressionAttributeValues: values,
+	}}}
+	delta, err := lineDelta(*cur, *it)
+	if err != nil {
+		it.Version = expected
+		return err
+	}
+	if delta.Amount != 0 {
+		ops = append(ops, r.orderTotalsUpdate(ctx, it.OrderID, 0, delta, it.UpdatedAt))
+	}
+	changes, err := r.itemChange(ctx, cur, it)
+	if err != nil {
+		it.Version = expected
+		return err
+	}
+	ops = append(ops, changes...)
+	if err := r.transactTotals(ctx, ops, it.Price.Currency); err != nil {
+		it.Version = expected
+		return mapTxErr(err, ErrVersionMismatch, ErrNotFound)
+	}
+	return nil
 }
 
+// DeleteOrderItem removes the item and subtracts it from the order's totals in
+// one transaction. Deleting a missing item is not an error.
 func (r *DynamoRepository) DeleteOrderItem(ctx context.Context, orderID, id string) error {
-	_, err := r.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &r.orderItemsTable,
-		Key: map[string]types.AttributeValue{
-			"order_id": &types.AttributeValueMemberS{Value: orderID},
-			"id":       &types.AttributeValueMemberS{Value: id},
-		},
-	})
+	var err error
+	// Retry when the item changes between the read and the conditional delete.
+	for attempt := 0; attempt < 3; attempt++ {
+		var cur *models.OrderItem
+		cur, err = r.getOrderItem(ctx, orderID, id, true)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var changes []types.TransactWriteItem
+		if changes, err = r.itemChange(ctx, cur, nil); err != nil {
+			return err
+		}
+		var line models.Money
+		if line, err = cur.LineTotal(); err != nil {
+			return err
+		}
+		ops := append(r.itemDeletes(ctx, []models.OrderItem{*cur}),
+			r.orderTotalsUpdate(ctx, orderID, -1, line.Neg(), time.Now().UTC().Format(time.RFC3339)))
+		ops = append(ops, changes...)
+		err = mapTxErr(r.transactTotals(ctx, ops, line.Currency), ErrVersionMismatch, ErrNotFound)
+		if !errors.Is(err, ErrVersionMismatch) {
+			return err
+		}
+	}
 	return err
 }
 
+func orderKey(ctx context.Context, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": scopedS(ctx, id)}
+}
+
+func itemKey(ctx context.Context, orderID, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"order_id": scopedS(ctx, orderID),
+		"id":       &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+// versionCondition requires the stored record to exist at the expected version.
+// Records written before versioning have no version attribute and match expected == 0.
+func versionCondition(keyAttr string, expected int64) (*string, map[string]types.AttributeValue) {
+	if expected == 0 {
+		return awsString("attribute_exists(" + keyAttr + ") AND attribute_not_exists(version)"), nil
+	}
+	return awsString("attribute_exists(" + keyAttr + ") AND version = :expected"), map[string]types.AttributeValue{
+		":expected": &types.AttributeValueMemberN{Value: strconv.FormatInt(expected, 10)},
+	}
+}
+
 func awsString(s string) *string { return &s }
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+)
+
+func TestRepairTotalsReadsItemsConsistently(t *testing.T) {
+	ctx := context.Background()
+	order, err := marshalScoped(ctx, testOrder("o1"), orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := marshalScoped(ctx, testItem("o1", "i1"), itemTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		switch in.(type) {
+		case *dynamodb.GetItemInput:
+			return &dynamodb.GetItemOutput{Item: order}, nil
+		case *dynamodb.QueryInput:
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}}, nil
+		}
+		return nil, nil
+	}}
+	r := NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", "")
+	changed, err := r.RepairTotals(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("RepairTotals reported no change for an order whose totals miss an item")
+	}
+
+	queries := inputs[*dynamodb.QueryInput](fake)
+	if len(queries) != 1 || queries[0].ConsistentRead == nil || !*queries[0].ConsistentRead {
+		t.Errorf("item queries = %+v, want one consistent read", queries)
+	}
+	txs := inputs[*dynamodb.TransactWriteItemsInput](fake)
+	if len(txs) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(txs))
+	}
+	var repaired models.Order
+	for _, op := range txs[0].TransactItems {
+		if op.Put != nil && *op.Put.TableName == "orders" {
+			if err := unmarshalScoped(ctx, op.Put.Item, orderTenantAttrs, &repaired); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := models.Money{Amount: 300, Currency: "USD"}
+	if repaired.ItemCount != 1 || repaired.Subtotal != want || repaired.Total != want {
+		t.Errorf("repaired totals = %d, %+v, %+v; want 1, %+v, %+v", repaired.ItemCount, repaired.Subtotal, repaired.Total, want, want)
+	}
+}
+
+func TestUpdateOrderEnforcesLifecycle(t *testing.T) {
+	ctx := context.Background()
+	cur := testOrder("o1")
+	cur.Version = 1 // as CreateOrder stores it
+	stored, err := marshalScoped(ctx, cur, orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		if _, ok := in.(*dynamodb.GetItemInput); ok {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		}
+		return nil, nil
+	}}
+	mem := NewMemoryRepository(nil)
+	if err := mem.CreateOrder(ctx, testOrder("o1")); err != nil {
+		t.Fatal(err)
+	}
+	repos := map[string]Repository{
+		"dynamo": NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", ""),
+		"memory": mem,
+	}
+	for name, r := range repos {
+		t.Run(name, func(t *testing.T) {
+			o := testOrder("o1")
+			o.Version = 1
+			o.Status = models.StatusDelivered
+			if err := r.UpdateOrder(ctx, o); !errors.Is(err, ErrInvalidTransition) || o.Version != 1 {
+				t.Errorf("new -> delivered: err = %v, version %d; want ErrInvalidTransition and version 1", err, o.Version)
+			}
+			o.Status = models.StatusConfirmed
+			if err := r.UpdateOrder(ctx, o); err != nil {
+				t.Errorf("new -> confirmed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// Tenant isolation in DynamoDB
+//
+// When ctx carries a tenant (see tenant.NewContext), every partition key
+// value — the tables' id and order_id and the owner, status and customer_name
+// index keys — is stored as "<tenant>#<value>", and records get a tenant_id
+// attribute. Keys built from a request can therefore only address that
+// tenant's partitions, and decoding rejects any record whose tenant_id does
+// not match, so no method returns another tenant's data even from a scan.
+// Without a tenant, values are stored unprefixed and only records without
+// tenant_id are visible, which keeps single-tenant deployments unchanged.
+
+// Attributes holding tenant-scoped values.
+var (
+	orderTenantAttrs = []string{"id", "owner_id", "status", "customer_name"}
+	itemTenantAttrs  = []string{"order_id"}
+	eventTenantAttrs = []string{"order_id"}
+)
+
+const tenantAttr = "tenant_id"
+
+// scoped prefixes v with the tenant of ctx.
+func scoped(ctx context.Context, v string) string {
+	if t := tenant.FromContext(ctx); t != "" {
+		return t + tenant.Separator + v
+	}
+	return v
+}
+
+func scopedS(ctx context.Context, v string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: scoped(ctx, v)}
+}
+
+// marshalScoped marshals v and scopes attrs to the tenant of ctx.
+func marshalScoped(ctx context.Context, v any, attrs []string) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attrs {
+		if s, ok := item[a].(*types.AttributeValueMemberS); ok {
+			item[a] = scopedS(ctx, s.Value)
+		}
+	}
+	if t := tenant.FromContext(ctx); t != "" {
+		item[tenantAttr] = &types.AttributeValueMemberS{Value: t}
+	}
+	return item, nil
+}
+
+// ownedBy reports whether item belongs to the tenant of ctx.
+func ownedBy(ctx context.Context, item map[string]types.AttributeValue) bool {
+	t := tenant.FromContext(ctx)
+	v, ok := item[tenantAttr].(*types.AttributeValueMemberS)
+	if t == "" {
+		return !ok
+	}
+	return ok && v.Value == t
+}
+
+// unmarshalScoped strips the tenant prefix from attrs and unmarshals item
+// into out. It returns ErrNotFound if item belongs to another tenant.
+func unmarshalScoped(ctx context.Context, item map[string]types.AttributeValue, attrs []string, out any) error {
+	if !ownedBy(ctx, item) {
+		return ErrNotFound
+	}
+	if t := tenant.FromContext(ctx); t != "" {
+		stripped := make(map[string]types.AttributeValue, len(item))
+		for k, v := range item {
+			stripped[k] = v
+		}
+		for _, a := range attrs {
+			if s, ok := item[a].(*types.AttributeValueMemberS); ok {
+				stripped[a] = &types.AttributeValueMemberS{Value: strings.TrimPrefix(s.Value, t+tenant.Separator)}
+			}
+		}
+		item = stripped
+	}
+	return attributevalue.UnmarshalMap(item, out)
+}
+
+// unmarshalScopedList decodes the items belonging to the tenant of ctx, skipping others.
+func unmarshalScopedList[T any](ctx context.Context, items []map[string]types.AttributeValue, attrs []string) ([]T, error) {
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		if !ownedBy(ctx, item) {
+			continue
+		}
+		var v T
+		if err := unmarshalScoped(ctx, item, attrs, &v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// tenantContext scopes ctx to the tenant a raw record belongs to, for
+// maintenance tasks that scan across tenants.
+func tenantContext(ctx context.Context, item map[string]types.AttributeValue) context.Context {
+	if v, ok := item[tenantAttr].(*types.AttributeValueMemberS); ok {
+		return tenant.NewContext(ctx, v.Value)
+	}
+	return tenant.NewContext(ctx, "")
+}
+
+// DecodeOrder decodes a raw orders table record, such as a stream image, and
+// returns the tenant it belongs to.
+func DecodeOrder(item map[string]types.AttributeValue) (*models.Order, string, error) {
+	ctx := tenantContext(context.Background(), item)
+	var o models.Order
+	if err := unmarshalScoped(ctx, item, orderTenantAttrs, &o); err != nil {
+		return nil, "", err
+	}
+	return &o, tenant.FromContext(ctx), nil
+}
+
+// DecodeOrderItem is DecodeOrder for order_items records.
+func DecodeOrderItem(item map[string]types.AttributeValue) (*models.OrderItem, string, error) {
+	ctx := tenantContext(context.Background(), item)
+	var it models.OrderItem
+	if err := unmarshalScoped(ctx, item, itemTenantAttrs, &it); err != nil {
+		return nil, "", err
+	}
+	return &it, tenant.FromContext(ctx), nil
+}
+
+// tenantFilter restricts a scan to the tenant of ctx, adding its value to values.
+func tenantFilter(ctx context.Context, values map[string]types.AttributeValue) string {
+	t := tenant.FromContext(ctx)
+	if t == "" {
+		return "attribute_not_exists(" + tenantAttr + ")"
+	}
+	values[":tenant"] = &types.AttributeValueMemberS{Value: t}
+	return tenantAttr + " = :tenant"
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+func testOrder(id string) *models.Order {
+	return &models.Order{
+		ID: id, CustomerName: "alice", OwnerID: "u1", Status: models.StatusNew,
+		CreatedAt: "2026-01-01T00:00:00Z", UpdatedAt: "2026-01-01T00:00:00Z",
+		Subtotal: models.Money{Currency: "USD"}, Total: models.Money{Currency: "USD"},
+	}
+}
+
+func testItem(orderID, id string) *models.OrderItem {
+	return &models.OrderItem{
+		OrderID: orderID, ID: id, ProductName: "pen", Quantity: 2,
+		Price:     models.Money{Amount: 150, Currency: "USD"},
+		CreatedAt: "2026-01-01T00:00:00Z", UpdatedAt: "2026-01-01T00:00:00Z",
+	}
+}
+
+func TestMemoryRepositoryTenantIsolation(t *testing.T) {
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+	r := NewMemoryRepository(nil)
+	if err := r.CreateOrder(globex, testOrder("o1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateOrderItem(globex, testItem("o1", "i1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.GetOrder(acme, "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrder = %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetOrderItem(acme, "o1", "i1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrderItem = %v, want ErrNotFound", err)
+	}
+	if orders, _ := r.ListOrders(acme); len(orders) != 0 {
+		t.Errorf("ListOrders = %v, want none", orders)
+	}
+	for _, f := range []OrderFilter{{}, {OwnerID: "u1"}, {Status: models.StatusNew}, {CustomerName: "alice"}} {
+		if page, _ := r.QueryOrders(acme, f, PageRequest{}); len(page.Items) != 0 {
+			t.Errorf("QueryOrders(%+v) = %v, want none", f, page.Items)
+		}
+	}
+	if items, _ := r.ListOrderItems(acme, "o1"); len(items) != 0 {
+		t.Errorf("ListOrderItems = %v, want none", items)
+	}
+	if page, _ := r.ListOrderItemsPage(acme, "o1", PageRequest{}); len(page.Items) != 0 {
+		t.Errorf("ListOrderItemsPage = %v, want none", page.Items)
+	}
+	if page, _ := r.ListOrderHistory(acme, "o1", PageRequest{}); len(page.Items) != 0 {
+		t.Errorf("ListOrderHistory = %v, want none", page.Items)
+	}
+
+	o := testOrder("o1")
+	o.Version = 1
+	if err := r.UpdateOrder(acme, o); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateOrder = %v, want ErrNotFound", err)
+	}
+	if _, err := r.TransitionOrder(acme, "o1", models.StatusNew, models.StatusConfirmed, o.UpdatedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TransitionOrder = %v, want ErrNotFound", err)
+	}
+	it := testItem("o1", "i1")
+	it.Version = 1
+	if err := r.UpdateOrderItem(acme, it); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateOrderItem = %v, want ErrNotFound", err)
+	}
+	if err := r.DeleteOrderItem(acme, "o1", "i1"); err != nil {
+		t.Errorf("DeleteOrderItem = %v", err)
+	}
+	if err := r.DeleteOrder(acme, "o1"); err != nil {
+		t.Errorf("DeleteOrder = %v", err)
+	}
+	// The same ID is free in another tenant
+	if err := r.CreateOrder(acme, testOrder("o1")); err != nil {
+		t.Errorf("CreateOrder with another tenant's ID = %v", err)
+	}
+
+	got, err := r.GetOrder(globex, "o1")
+	if err != nil || got.Version != 2 || got.ItemCount != 1 {
+		t.Fatalf("globex order = %+v, %v; want it untouched with its item", got, err)
+	}
+	if _, err := r.GetOrderItem(globex, "o1", "i1"); err != nil {
+		t.Errorf("globex item: %v", err)
+	}
+	if page, _ := r.ListOrderHistory(globex, "o1", PageRequest{}); len(page.Items) != 2 {
+		t.Errorf("globex history has %d events, want 2", len(page.Items))
+	}
+}
+
+func TestScopedEncoding(t *testing.T) {
+	acme := tenant.NewContext(context.Background(), "acme")
+	item, err := marshalScoped(acme, testOrder("o1"), orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for attr, want := range map[string]string{
+		"id": "acme#o1", "owner_id": "acme#u1", "status": "acme#new", "customer_name": "acme#alice",
+		"tenant_id": "acme", "created_at": "2026-01-01T00:00:00Z",
+	} {
+		if got := stringAttr(item, attr); got != want {
+			t.Errorf("%s = %q, want %q", attr, got, want)
+		}
+	}
+	if got := stringAttr(orderKey(acme, "o1"), "id"); got != "acme#o1" {
+		t.Errorf("orderKey id = %q, want acme#o1", got)
+	}
+	key := itemKey(acme, "o1", "i1")
+	if stringAttr(key, "order_id") != "acme#o1" || stringAttr(key, "id") != "i1" {
+		t.Errorf("itemKey = %v, want order_id acme#o1 and id i1", key)
+	}
+
+	var o models.Order
+	if err := unmarshalScoped(acme, item, orderTenantAttrs, &o); err != nil || o.ID != "o1" || o.OwnerID != "u1" || o.Status != models.StatusNew {
+		t.Errorf("unmarshalScoped = %+v, %v", o, err)
+	}
+	for name, ctx := range map[string]context.Context{
+		"other tenant": tenant.NewContext(context.Background(), "globex"),
+		"no tenant":    context.Background(),
+	} {
+		if err := unmarshalScoped(ctx, item, orderTenantAttrs, &o); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: unmarshalScoped = %v, want ErrNotFound", name, err)
+		}
+	}
+	unscoped, err := marshalScoped(context.Background(), testOrder("o1"), orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unmarshalScoped(acme, unscoped, orderTenantAttrs, &o); !errors.Is(err, ErrNotFound) {
+		t.Errorf("record without a tenant: unmarshalScoped = %v, want ErrNotFound", err)
+	}
+}
+
+// TestDynamoRepositoryTenantIsolation checks that every key DynamoRepository
+// sends is scoped to the caller's tenant, and that it still refuses another
+// tenant's records if DynamoDB were to return them.
+func TestDynamoRepositoryTenantIsolation(t *testing.T) {
+	globex := tenant.NewContext(context.Background(), "globex")
+	foreignOrder, err := marshalScoped(globex, testOrder("o1"), orderTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignItem, err := marshalScoped(globex, testItem("o1", "i1"), itemTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignEvent, err := marshalScoped(globex, models.AuditEvent{OrderID: "o1", At: "2026-01-01T00:00:00Z", Operation: "create"}, eventTenantAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign := map[string]map[string]types.AttributeValue{"orders": foreignOrder, "order_items": foreignItem, "order_events": foreignEvent}
+
+	fake := &fakeDynamo{respond: func(in any) (any, error) {
+		switch in := in.(type) {
+		case *dynamodb.GetItemInput:
+			return &dynamodb.GetItemOutput{Item: foreign[*in.TableName]}, nil
+		case *dynamodb.QueryInput:
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{foreign[*in.TableName]}}, nil
+		case *dynamodb.ScanInput:
+			return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{foreign[*in.TableName]}}, nil
+		}
+		return nil, nil
+	}}
+	r := NewDynamoRepository(fake.client(), "orders", "order_items", "order_events", "")
+	acme := tenant.NewContext(context.Background(), "acme")
+
+	if _, err := r.GetOrder(acme, "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrder = %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetOrderItem(acme, "o1", "i1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrderItem = %v, want ErrNotFound", err)
+	}
+	for _, f := range []OrderFilter{{}, {OwnerID: "u1"}, {Status: models.StatusNew}, {CustomerName: "alice"}} {
+		if page, err := r.QueryOrders(acme, f, PageRequest{}); err != nil || len(page.Items) != 0 {
+			t.Errorf("QueryOrders(%+v) = %v, %v; want none", f, page.Items, err)
+		}
+	}
+	if items, err := r.ListOrderItems(acme, "o1"); err != nil || len(items) != 0 {
+		t.Errorf("ListOrderItems = %v, %v; want none", items, err)
+	}
+	if page, err := r.ListOrderItemsPage(acme, "o1", PageRequest{}); err != nil || len(page.Items) != 0 {
+		t.Errorf("ListOrderItemsPage = %v, %v; want none", page.Items, err)
+	}
+	if page, err := r.ListOrderHistory(acme, "o1", PageRequest{}); err != nil || len(page.Items) != 0 {
+		t.Errorf("ListOrderHistory = %v, %v; want none", page.Items, err)
+	}
+	o := testOrder("o1")
+	o.Version = 1
+	if err := r.UpdateOrder(acme, o); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateOrder = %v, want ErrNotFound", err)
+	}
+	if _, err := r.TransitionOrder(acme, "o1", models.StatusNew, models.StatusConfirmed, o.UpdatedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TransitionOrder = %v, want ErrNotFound", err)
+	}
+	it := testItem("o1", "i1")
+	it.Version = 1
+	if err := r.UpdateOrderItem(acme, it); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateOrderItem = %v, want ErrNotFound", err)
+	}
+	if err := r.DeleteOrderItem(acme, "o1", "i1"); err != nil {
+		t.Errorf("DeleteOrderItem = %v", err)
+	}
+	if err := r.DeleteOrder(acme, "o1"); err != nil {
+		t.Errorf("DeleteOrder = %v", err)
+	}
+
+	if writes := inputs[*dynamodb.TransactWriteItemsInput](fake); len(writes) != 0 {
+		t.Errorf("%d writes issued for another tenant's records", len(writes))
+	}
+	scopedToAcme := func(what string, v types.AttributeValue) {
+		t.Helper()
+		if s, ok := v.(*types.AttributeValueMemberS); !ok || !strings.HasPrefix(s.Value, "acme#") {
+			t.Errorf("%s = %v, want an acme# value", what, v)
+		}
+	}
+	for _, in := range inputs[*dynamodb.GetItemInput](fake) {
+		if *in.TableName == "orders" {
+			scopedToAcme("orders key id", in.Key["id"])
+		} else {
+			scopedToAcme("order_items key order_id", in.Key["order_id"])
+		}
+	}
+	for _, in := range inputs[*dynamodb.QueryInput](fake) {
+		v, ok := in.ExpressionAttributeValues[":oid"]
+		if !ok {
+			v = in.ExpressionAttributeValues[":pk"]
+		}
+		scopedToAcme(*in.TableName+" query key", v)
+	}
+	for _, in := range inputs[*dynamodb.ScanInput](fake) {
+		if got := stringAttr(in.ExpressionAttributeValues, ":tenant"); got != "acme" || !strings.Contains(*in.FilterExpression, "tenant_id = :tenant") {
+			t.Errorf("scan filter = %s with :tenant %q, want it restricted to acme", *in.FilterExpression, got)
+		}
+	}
+}
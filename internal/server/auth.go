@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+)
+
+// Authenticate requires a valid "Authorization: Bearer <jwt>" or X-API-Key
+// header on every route except those whose pattern (as in c.FullPath(), e.g.
+// "/swagger/*any") is listed in exempt. The caller is stored in the Gin
+// context under auth.SubjectKey and auth.ClaimsKey and in the request context
+// (see auth.FromContext). Either verifier may be nil to disable that scheme;
+// with both nil authentication is disabled.
+func Authenticate(v *auth.Verifier, keys *apikeys.Authenticator, exempt []string) gin.HandlerFunc {
+	skip := map[string]bool{}
+	for _, p := range exempt {
+		skip[p] = true
+	}
+	return func(c *gin.Context) {
+		if v == nil && keys == nil || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if key := c.GetHeader("X-API-Key"); key != "" && keys != nil {
+			k, err := keys.Verify(c.Request.Context(), key)
+			switch {
+			case errors.Is(err, apikeys.ErrInvalidKey):
+				problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid api key")
+				return
+			case err != nil:
+				_ = c.Error(err)
+				problem.Write(c, http.StatusServiceUnavailable, problem.CodeUnavailable, "api key store unavailable")
+				return
+			}
+			setPrincipal(c, k.Principal())
+			c.Set(auth.APIKeyIDKey, k.ID)
+			c.Next()
+			return
+		}
+		if v == nil {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "missing api key")
+			return
+		}
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="orders-api"`)
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "missing bearer token")
+			return
+		}
+		p, err := v.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			_ = c.Error(err)
+			c.Header("WWW-Authenticate", `Bearer realm="orders-api", error="invalid_token"`)
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid or expired token")
+			return
+		}
+		setPrincipal(c, p)
+		c.Next()
+	}
+}
+
+func setPrincipal(c *gin.Context, p *auth.Principal) {
+	c.Set(auth.SubjectKey, p.Subject)
+	c.Set(auth.ClaimsKey, p.Claims)
+	c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), p))
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/http/problem"
+)
+
+// CORSConfig configures cross-origin access for browser clients.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins ("https://admin.example.com"), origins
+	// with a wildcard subdomain ("https://*.example.com", which does not
+	// match the bare domain) or "*" for any origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string      // response headers scripts may read, e.g. ETag
+	AllowCredentials bool          // ignored for "*", which would let any site act as the user
+	MaxAge           time.Duration // how long browsers may cache a preflight result
+}
+
+// CORS answers preflight requests and adds CORS headers to
+// responses for allowed origins. It must run before Authenticate, since
+// browsers send preflights without credentials. Preflights from origins that
+// aren't allowed get 403; other requests from them are served without CORS
+// headers, so the browser withholds the response. A nil cfg disables CORS.
+func CORS(cfg *CORSConfig) gin.HandlerFunc {
+	if cfg == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	anyOrigin := false
+	for _, o := range cfg.AllowedOrigins {
+		anyOrigin = anyOrigin || o == "*"
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		if !anyOrigin && !originAllowed(cfg.AllowedOrigins, origin) {
+			if preflight {
+				problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "origin not allowed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// Browsers only send credentials to an echoed origin, never to "*".
+		// Echoing every origin with credentials would let any site call the
+		// API as the signed-in user, so "*" never allows them.
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+		if !preflight {
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", headers)
+		if cfg.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		scheme, host, ok := strings.Cut(a, "://*.")
+		if !ok {
+			if a == origin {
+				return true
+			}
+			continue
+		}
+		// "https://*.example.com" matches "https://<sub>.example.com".
+		rest, ok := strings.CutPrefix(origin, scheme+"://")
+		if !ok {
+			continue
+		}
+		sub, ok := strings.CutSuffix(rest, "."+host)
+		if ok && sub != "" && !strings.ContainsAny(sub, "/:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		allowed    []string
+		wantOrigin string
+		wantCreds  string
+	}{
+		{"listed origin", []string{"https://app.example.com"}, "https://app.example.com", "true"},
+		{"wildcard subdomain", []string{"https://*.example.com"}, "https://app.example.com", "true"},
+		{"any origin", []string{"*"}, "*", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORS(&CORSConfig{AllowedOrigins: tt.allowed, AllowedMethods: []string{http.MethodGet}, AllowCredentials: true}))
+			r.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+			for _, preflight := range []bool{false, true} {
+				req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+				if preflight {
+					req.Method = http.MethodOptions
+					req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+				}
+				req.Header.Set("Origin", "https://app.example.com")
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("preflight %v: Access-Control-Allow-Origin = %q, want %q", preflight, got, tt.wantOrigin)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+					t.Errorf("preflight %v: Access-Control-Allow-Credentials = %q, want %q", preflight, got, tt.wantCreds)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/idempotency"
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header, which becomes a DynamoDB key.
+const maxIdempotencyKeyLen = 255
+
+// maxIdempotentBodyLen bounds the body read into memory to hash it and, in
+// the stored record, replayed from DynamoDB's 400 KB items.
+const maxIdempotentBodyLen = 256 << 10
+
+// releaseTimeout bounds releasing a claim after the request's own deadline passed.
+const releaseTimeout = 5 * time.Second
+
+// replayedHeaders are the response headers stored and replayed with the body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency honors the Idempotency-Key request header. The first request
+// with a key is processed normally and its response stored for ttl; repeats
+// with the same method, path and body get the stored response replayed
+// (marked with Idempotent-Replayed: true). Reusing a key for a different
+// request is rejected with 422, and a repeat that arrives while the first is
+// still running gets 409. Requests without the header pass through. Each
+// tenant and, within it, each authenticated caller has its own key space, so
+// no caller can claim or replay another's key.
+//
+// The claim on a key is released when the request fails with a 5xx or
+// panics. If the process dies before that, the claim lapses after lease, which
+// must exceed the request timeout, so the key does not stay in progress until
+// ttl.
+func Idempotency(store idempotency.Store, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if store == nil || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Idempotency-Key is too long")
+			return
+		}
+		subject := c.GetString(auth.SubjectKey)
+		key = idempotencyKey(tenant.FromContext(c.Request.Context()), subject, key)
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyLen))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Write(c, http.StatusRequestEntityTooLarge, problem.CodeInvalidRequest,
+				"request body is too large for an Idempotency-Key request")
+			return
+		}
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, "could not read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, subject, body)
+
+		ctx := c.Request.Context()
+		token := uuid.NewString()
+		existing, err := store.Claim(ctx, key, hash, token, lease)
+		if err != nil {
+			_ = c.Error(err)
+			problem.Write(c, http.StatusServiceUnavailable, problem.CodeUnavailable, "idempotency store unavailable")
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != hash:
+				problem.Write(c, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused,
+					"Idempotency-Key was already used for a different request")
+			case !existing.Completed():
+				c.Header("Retry-After", "1")
+				problem.Write(c, http.StatusConflict, problem.CodeIdempotencyInProgress,
+					"a request with this Idempotency-Key is still being processed")
+			default:
+				for k, v := range existing.Header {
+					c.Header(k, v)
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.Header["Content-Type"], existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// Server errors and panics are not cached so the client can retry with
+		// the same key. The release also runs while a panic unwinds to Recovery,
+		// and outlives the request's deadline.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+			defer cancel()
+			if err := store.Release(ctx, key, token); errors.Is(err, idempotency.ErrLeaseLost) {
+				logging.FromContext(ctx).WarnContext(ctx, "idempotency: lease lost before release", "key", key)
+			} else if err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "idempotency: release failed", "key", key, "error", err)
+			}
+		}()
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		stored := &idempotency.Record{
+			Key:         key,
+			RequestHash: hash,
+			Token:       token,
+			StatusCode:  c.Writer.Status(),
+			Header:      map[string]string{},
+			Body:        rec.body.Bytes(),
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			ExpiresAt:   time.Now().Add(ttl).Unix(),
+		}
+		for _, h := range replayedHeaders {
+			if v := c.Writer.Header().Get(h); v != "" {
+				stored.Header[h] = v
+			}
+		}
+		if err := store.Complete(ctx, stored); errors.Is(err, idempotency.ErrLeaseLost) {
+			logging.FromContext(ctx).WarnContext(ctx, "idempotency: lease lost before complete", "key", key)
+		} else if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "idempotency: complete failed", "key", key, "error", err)
+		}
+	}
+}
+
+// idempotencyKey scopes a client's key to its tenant and subject. The subject
+// is escaped so it cannot contain the separator.
+func idempotencyKey(tenantID, subject, key string) string {
+	if subject != "" {
+		key = url.PathEscape(subject) + tenant.Separator + key
+	}
+	if tenantID != "" {
+		key = tenantID + tenant.Separator + key
+	}
+	return key
+}
+
+func requestHash(method, path, subject string, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, method+" "+path+"\n"+subject+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder tees the response body so it can be stored for replay.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
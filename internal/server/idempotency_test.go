@@ -0,0 +1,180 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/idempotency"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+func idempotentRequest(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReleasesOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(Recovery())
+	r.POST("/orders", Idempotency(idempotency.NewMemoryStore(), time.Hour, time.Minute), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	if w := idempotentRequest(r, "k1", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request: status = %d, want 500", w.Code)
+	}
+	w := idempotentRequest(r, "k1", `{}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("retry after panic: status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	w = idempotentRequest(r, "k1", `{}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" || calls != 2 {
+		t.Errorf("replay: status = %d, replayed = %q, calls = %d; want 201, true, 2",
+			w.Code, w.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
+
+func TestIdempotencyLeaseExpires(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := idempotency.NewMemoryStore()
+	// A claim left behind by a process that died mid-request
+	if _, err := store.Claim(t.Context(), "k1", requestHash(http.MethodPost, "/orders", "", []byte(`{}`)), "crashed", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.POST("/orders", Idempotency(store, time.Hour, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	if w := idempotentRequest(r, "k1", `{}`); w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201 once the lease has run out: %s", w.Code, w.Body.String())
+	}
+	// A live claim still blocks concurrent retries
+	if _, err := store.Claim(t.Context(), "k2", requestHash(http.MethodPost, "/orders", "", []byte(`{}`)), "crashed", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if w := idempotentRequest(r, "k2", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409 while the lease holds", w.Code)
+	}
+}
+
+func TestIdempotencyLeaseLost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	var retry *httptest.ResponseRecorder
+	// Every claim's lease has already run out, so a retry can take the key over
+	r.POST("/orders", Idempotency(idempotency.NewMemoryStore(), time.Hour, -time.Second), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			retry = idempotentRequest(r, "k1", `{}`)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	if w := idempotentRequest(r, "k1", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request: status = %d, want 500", w.Code)
+	}
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("retry: status = %d, want 201", retry.Code)
+	}
+	// The first request's release must not delete the retry's record
+	w := idempotentRequest(r, "k1", `{}`)
+	if w.Header().Get("Idempotent-Replayed") != "true" || calls != 2 {
+		t.Errorf("replayed = %q, calls = %d; want the retry's response replayed", w.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/orders", Idempotency(idempotency.NewMemoryStore(), time.Hour, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	body := `{"x":"` + strings.Repeat("a", maxIdempotentBodyLen) + `"}`
+	if w := idempotentRequest(r, "k1", body); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", w.Code)
+	}
+}
+
+func TestIdempotencyKeysPerTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(Tenant(&tenant.Resolver{Header: "X-Tenant-ID"}, nil))
+	r.POST("/orders", Idempotency(idempotency.NewMemoryStore(), time.Hour, time.Minute), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"tenant": tenant.FromContext(c.Request.Context())})
+	})
+	post := func(tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		req.Header.Set("X-Tenant-ID", tenantID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, id := range []string{"acme", "globex"} {
+		w := post(id)
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" || !strings.Contains(w.Body.String(), id) {
+			t.Errorf("%s: status = %d, replayed = %q, body = %s; want its own 201",
+				id, w.Code, w.Header().Get("Idempotent-Replayed"), w.Body.String())
+		}
+	}
+	if w := post("acme"); w.Header().Get("Idempotent-Replayed") != "true" || !strings.Contains(w.Body.String(), "acme") {
+		t.Errorf("acme repeat: replayed = %q, body = %s; want acme's response replayed", w.Header().Get("Idempotent-Replayed"), w.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyKeysPerCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(auth.SubjectKey, c.GetHeader("X-Subject")) })
+	r.POST("/orders", Idempotency(idempotency.NewMemoryStore(), time.Hour, time.Minute), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"subject": c.GetString(auth.SubjectKey)})
+	})
+	post := func(subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		req.Header.Set("X-Subject", subject)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, sub := range []string{"alice", "bob"} {
+		w := post(sub)
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" || !strings.Contains(w.Body.String(), sub) {
+			t.Errorf("%s: status = %d, replayed = %q, body = %s; want its own 201",
+				sub, w.Code, w.Header().Get("Idempotent-Replayed"), w.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+	if idempotencyKey("acme", "bob#x", "k1") == idempotencyKey("acme", "bob", "x#k1") {
+		t.Error("a subject containing the separator shares another subject's key space")
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// RequestIDKey is the Gin context key holding the request ID.
+const RequestIDKey = "request_id"
+
+// maxRequestIDLen bounds a client-supplied X-Request-ID.
+const maxRequestIDLen = 128
+
+// RequestID assigns each request an ID and a logger carrying it, stored in
+// the request context (see logging.FromContext). A well-formed X-Request-ID
+// header is kept so callers can correlate logs across services; otherwise
+// the API Gateway request ID is used in Lambda mode, or a new UUID. The ID is
+// echoed in the X-Request-ID response header.
+func RequestID(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var attrs []any
+		apigw, hasAPIGW := core.GetAPIGatewayContextFromContext(ctx)
+		id := c.GetHeader("X-Request-ID")
+		switch {
+		case validRequestID(id):
+			if hasAPIGW {
+				attrs = append(attrs, "apigw_request_id", apigw.RequestID)
+			}
+		case hasAPIGW && apigw.RequestID != "":
+			id = apigw.RequestID
+		default:
+			id = uuid.NewString()
+		}
+		attrs = append([]any{RequestIDKey, id}, attrs...)
+		if lc, ok := core.GetRuntimeContextFromContext(ctx); ok {
+			attrs = append(attrs, "lambda_request_id", lc.AwsRequestID)
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Request = c.Request.WithContext(logging.NewContext(ctx, base.With(attrs...)))
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog writes one JSON log line per request with the request's logger.
+// Server errors are logged at error level with the errors handlers recorded
+// via c.Error.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ctx := c.Request.Context()
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", clientIP(c),
+			"user_agent", c.Request.UserAgent(),
+		}
+		if p, ok := auth.FromContext(ctx); ok {
+			attrs = append(attrs, "subject", p.Subject)
+		}
+		if t := tenant.FromContext(ctx); t != "" {
+			attrs = append(attrs, "tenant", t)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.Errors())
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logging.FromContext(ctx).Log(ctx, level, "request", attrs...)
+	}
+}
+
+// Recovery turns panics into 500 problem responses, logging the panic and
+// stack with the request's logger.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).ErrorContext(ctx, "panic", "error", fmt.Sprint(err), "stack", string(debug.Stack()))
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "internal error")
+	})
+}
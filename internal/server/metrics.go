@@ -0,0 +1,27 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/metrics"
+)
+
+// Metrics records each request's latency with rec, labelled by route template
+// rather than raw path so IDs don't explode cardinality. A nil rec disables it.
+func Metrics(rec metrics.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rec == nil {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		rec.ObserveRequest(c.Request.Context(), c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
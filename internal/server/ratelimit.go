@@ -0,0 +1,85 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/ratelimit"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// RateLimit limits each client of a route group to l. Clients are identified
+// by API key, else by JWT subject (within their tenant), else by IP (see
+// clientIP), so it must run after Authenticate. Responses carry RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers; over the limit the client
+// gets 429 with Retry-After. If the limiter fails the request is let through
+// rather than turning a counter outage into an API outage. A nil lim or a
+// zero l disables limiting.
+func RateLimit(lim ratelimit.Limiter, group string, l ratelimit.Limit) gin.HandlerFunc {
+	return rateLimit(lim, group, l, clientKey)
+}
+
+// RateLimitIP limits each client IP to l across all routes, like RateLimit
+// with the "ip" group. It runs before Authenticate, so requests with missing
+// or invalid credentials, which never reach a route group, are limited too.
+func RateLimitIP(lim ratelimit.Limiter, l ratelimit.Limit) gin.HandlerFunc {
+	return rateLimit(lim, "ip", l, func(c *gin.Context) string { return clientIP(c) })
+}
+
+func rateLimit(lim ratelimit.Limiter, group string, l ratelimit.Limit, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if lim == nil || l.Requests <= 0 {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		res, err := lim.Allow(ctx, group+":"+key(c), l)
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "rate limiter unavailable; allowing request", "group", group, "error", err)
+			c.Next()
+			return
+		}
+		c.Header("RateLimit-Limit", strconv.Itoa(l.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+		c.Header("RateLimit-Policy", strconv.Itoa(l.Requests)+";w="+ceilSeconds(l.Window))
+		if !res.Allowed {
+			c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+			problem.Write(c, http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded; retry after the Retry-After delay")
+			return
+		}
+		c.Next()
+	}
+}
+
+func clientKey(c *gin.Context) string {
+	if id := c.GetString(auth.APIKeyIDKey); id != "" {
+		return "key:" + id
+	}
+	if sub := c.GetString(auth.SubjectKey); sub != "" {
+		return "sub:" + tenant.FromContext(c.Request.Context()) + "/" + sub
+	}
+	return "ip:" + clientIP(c)
+}
+
+// clientIP returns the caller's address: in Lambda mode the source IP API
+// Gateway saw, otherwise Gin's ClientIP, which reads X-Forwarded-For only
+// from the engine's trusted proxies.
+func clientIP(c *gin.Context) string {
+	if apigw, ok := core.GetAPIGatewayContextFromContext(c.Request.Context()); ok && apigw.Identity.SourceIP != "" {
+		return apigw.Identity.SourceIP
+	}
+	return c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/ratelimit"
+)
+
+func TestClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newEngine := func(trusted []string) (*gin.Engine, *string) {
+		r := gin.New()
+		if err := r.SetTrustedProxies(trusted); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		r.GET("/orders", func(c *gin.Context) { got = clientIP(c) })
+		return r, &got
+	}
+	local := func(remote string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		return req
+	}
+	lambda := func() *http.Request {
+		req, err := (&core.RequestAccessor{}).EventToRequestWithContext(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodGet,
+			Path:       "/orders",
+			Headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.7"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		req     *http.Request
+		want    string
+	}{
+		{"no trusted proxies ignore X-Forwarded-For", nil, local("192.0.2.10:4711"), "192.0.2.10"},
+		{"untrusted peer", []string{"10.0.0.0/8"}, local("192.0.2.10:4711"), "192.0.2.10"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, local("10.1.2.3:4711"), "198.51.100.1"},
+		{"API Gateway source IP", nil, lambda(), "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, got := newEngine(tt.trusted)
+			r.ServeHTTP(httptest.NewRecorder(), tt.req)
+			if *got != tt.want {
+				t.Errorf("clientIP = %q, want %q", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIPBeforeAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimitIP(ratelimit.NewMemoryLimiter(), ratelimit.Limit{Requests: 2, Window: time.Minute}))
+	r.Use(Authenticate(nil, apikeys.NewAuthenticator(apikeys.NewMemoryStore(), time.Minute), nil))
+	r.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = "192.0.2.10:4711"
+		req.Header.Set("X-API-Key", "ak_unknown.secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
@@ -1,7 +1,18 @@
 package server
 
 import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/health"
 	"go-serverless-api-terraform/internal/http/handlers"
+	"go-serverless-api-terraform/internal/idempotency"
+	"go-serverless-api-terraform/internal/metrics"
+	"go-serverless-api-terraform/internal/ratelimit"
+	"go-serverless-api-terraform/internal/tenant"
 
 	"github.com/gin-gonic/gin"
 
@@ -11,26 +22,106 @@ import (
 	_ "go-serverless-api-terraform/docs"
 )
 
+// Options carries optional dependencies for NewRouter.
+type Options struct {
+	Idempotency      idempotency.Store // nil disables Idempotency-Key support
+	IdempotencyTTL   time.Duration
+	IdempotencyLease time.Duration // how long an in-progress claim holds its key; must exceed RequestTimeout
+
+	Auth            *auth.Verifier // nil disables JWT authentication
+	AuthExemptPaths []string       // route patterns served without a token, e.g. "/swagger/*any"
+
+	APIKeys        apikeys.Store // nil disables X-API-Key authentication and the key admin routes
+	APIKeyCacheTTL time.Duration
+
+	Tenants *tenant.Resolver // nil disables multi-tenancy; AuthExemptPaths are also served without a tenant
+
+	Logger *slog.Logger // base logger for access logs and per-request loggers; defaults to slog.Default()
+
+	Metrics        metrics.Recorder // nil disables request metrics
+	MetricsHandler http.Handler     // served on GET /metrics when non-nil
+
+	Readiness *health.Readiness // checks behind GET /readyz; nil reports ready with no checks
+
+	RequestTimeout time.Duration // context deadline for each request; 0 disables
+
+	RateLimiter ratelimit.Limiter          // nil disables rate limiting
+	RateLimits  map[string]ratelimit.Limit // per client IP before authentication ("ip") and per route group ("orders", "items" and "admin"); missing groups are unlimited
+
+	// TrustedProxies lists the IPs or CIDRs allowed to set X-Forwarded-For
+	// for the client IP; with none, the connection's address is used.
+	TrustedProxies []string
+
+	CORS *CORSConfig // nil disables CORS
+
+	Legacy   *LegacyRoutes      // nil drops the unversioned aliases of /v1
+	Versions map[string]Version // API versions besides /v1, by prefix, e.g. "/v2"
+}
+
+// LegacyRoutes keeps the pre-/v1 unversioned routes as deprecated aliases.
+type LegacyRoutes struct {
+	DeprecatedAt time.Time // zero if not yet announced
+	Sunset       time.Time // when the aliases will be removed; zero if not yet decided
+}
+
 // NewRouter builds the Gin engine and registers routes
-func NewRouter(h *handlers.Handler) *gin.Engine {
-	r := gin.Default()
+func NewRouter(h *handlers.Handler, opts Options) *gin.Engine {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := gin.New()
+	if err := r.SetTrustedProxies(opts.TrustedProxies); err != nil {
+		logger.Error("invalid trusted proxies; trusting none", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+	r.Use(RequestID(logger), AccessLog(), Metrics(opts.Metrics), Trace(), Recovery(), Timeout(opts.RequestTimeout))
+	var keyAuth *apikeys.Authenticator
+	if opts.APIKeys != nil {
+		keyAuth = apikeys.NewAuthenticator(opts.APIKeys, opts.APIKeyCacheTTL)
+	}
+	r.Use(CORS(opts.CORS))
+	r.Use(RateLimitIP(opts.RateLimiter, opts.RateLimits["ip"]))
+	r.Use(Authenticate(opts.Auth, keyAuth, opts.AuthExemptPaths))
+	r.Use(Tenant(opts.Tenants, opts.AuthExemptPaths))
+	idem := Idempotency(opts.Idempotency, opts.IdempotencyTTL, opts.IdempotencyLease)
+
+	// Probes
+	ready := opts.Readiness
+	if ready == nil {
+		ready = health.NewReadiness(0, 0)
+	}
+	probes := handlers.NewHealth(ready)
+	r.GET("/healthz", probes.Live)
+	r.GET("/readyz", probes.Ready)
+
+	if opts.MetricsHandler != nil {
+		r.GET("/metrics", gin.WrapH(opts.MetricsHandler))
+	}
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Orders routes
-	r.GET("/orders", h.ListOrders)
-	r.POST("/orders", h.CreateOrder)
-	r.GET("/orders/:orderId", h.GetOrder)
-	r.PUT("/orders/:orderId", h.UpdateOrder)
-	r.DELETE("/orders/:orderId", h.DeleteOrder)
-
-	// Order items routes
-	r.GET("/orders/:orderId/items", h.ListItems)
-	r.POST("/orders/:orderId/items", h.CreateItem)
-	r.GET("/orders/:orderId/items/:itemId", h.GetItem)
-	r.PUT("/orders/:orderId/items/:itemId", h.UpdateItem)
-	r.DELETE("/orders/:orderId/items/:itemId", h.DeleteItem)
+	var keys *handlers.APIKeys
+	if opts.APIKeys != nil {
+		keys = handlers.NewAPIKeys(opts.APIKeys, keyAuth)
+	}
+	mount := func(g *gin.RouterGroup, register Version) {
+		register(APIGroups{
+			Orders:     g.Group("", RateLimit(opts.RateLimiter, "orders", opts.RateLimits["orders"])),
+			Items:      g.Group("", RateLimit(opts.RateLimiter, "items", opts.RateLimits["items"])),
+			Admin:      g.Group("", RateLimit(opts.RateLimiter, "admin", opts.RateLimits["admin"])),
+			Idempotent: idem,
+		})
+	}
+	mount(r.Group("/v1"), v1(h, keys))
+	if opts.Legacy != nil {
+		// Unversioned aliases of /v1 for clients predating it
+		mount(r.Group("", Deprecation(opts.Legacy.DeprecatedAt, opts.Legacy.Sunset, "/v1")), v1(h, keys))
+	}
+	for prefix, register := range opts.Versions {
+		mount(r.Group(prefix), register)
+	}
 
 	return r
 }
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Serve runs srv until ctx is cancelled (e.g. on SIGTERM), then stops
+// accepting connections and waits up to drain for in-flight requests, such as
+// a cascading order delete, to finish. It returns nil after a clean drain.
+func Serve(ctx context.Context, srv *http.Server, drain time.Duration) error {
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down; draining in-flight requests", "drain", drain.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Drain period elapsed: cut the remaining connections.
+		_ = srv.Close()
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/http/problem"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// Tenant resolves each request's tenant with res and stores it in the request
+// context (see tenant.FromContext), which scopes every repository call. It
+// must run after Authenticate so the caller's own tenant takes precedence.
+// Callers not bound to a tenant may only choose one if they are admins, or
+// when authentication is disabled; others are refused.
+// Routes whose pattern is listed in exempt are served without a tenant; a nil
+// res disables tenancy.
+func Tenant(res *tenant.Resolver, exempt []string) gin.HandlerFunc {
+	skip := map[string]bool{}
+	for _, p := range exempt {
+		skip[p] = true
+	}
+	return func(c *gin.Context) {
+		if res == nil || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		callerTenant, mayChoose := "", true
+		if p, ok := auth.FromContext(c.Request.Context()); ok {
+			callerTenant, mayChoose = p.Tenant, p.HasRole(auth.RoleAdmin)
+		}
+		t, err := res.Resolve(c.Request, callerTenant, mayChoose)
+		switch {
+		case errors.Is(err, tenant.ErrMismatch), errors.Is(err, tenant.ErrUnbound):
+			problem.Write(c, http.StatusForbidden, problem.CodeForbidden, err.Error())
+			return
+		case err != nil:
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), t))
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/auth"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+func TestTenantResolution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		caller     *auth.Principal // nil when authentication is disabled
+		header     string
+		want       int
+		wantTenant string
+	}{
+		{"no auth, header", nil, "acme", http.StatusOK, "acme"},
+		{"no auth, no header", nil, "", http.StatusBadRequest, ""},
+		{"bound caller", &auth.Principal{Subject: "u1", Tenant: "acme"}, "", http.StatusOK, "acme"},
+		{"bound caller, same header", &auth.Principal{Subject: "u1", Tenant: "acme"}, "acme", http.StatusOK, "acme"},
+		{"bound caller, other header", &auth.Principal{Subject: "u1", Tenant: "acme"}, "globex", http.StatusForbidden, ""},
+		{"unbound admin, header", &auth.Principal{Subject: "u1", Roles: []string{auth.RoleAdmin}}, "globex", http.StatusOK, "globex"},
+		{"unbound admin, no header", &auth.Principal{Subject: "u1", Roles: []string{auth.RoleAdmin}}, "", http.StatusBadRequest, ""},
+		{"unbound customer, header", &auth.Principal{Subject: "u1"}, "globex", http.StatusForbidden, ""},
+		{"unbound support, header", &auth.Principal{Subject: "u1", Roles: []string{auth.RoleSupport}}, "globex", http.StatusForbidden, ""},
+		{"unbound customer, no header", &auth.Principal{Subject: "u1"}, "", http.StatusForbidden, ""},
+		{"invalid tenant", nil, "Not Valid", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.caller != nil {
+					c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), tt.caller))
+				}
+			})
+			r.Use(Tenant(&tenant.Resolver{Header: "X-Tenant-ID"}, nil))
+			var got string
+			r.GET("/orders", func(c *gin.Context) {
+				got = tenant.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want || got != tt.wantTenant {
+				t.Errorf("status = %d, tenant = %q; want %d, %q", w.Code, got, tt.want, tt.wantTenant)
+			}
+		})
+	}
+}
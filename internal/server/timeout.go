@@ -0,0 +1,24 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives each request a context deadline of d, which repository calls
+// inherit, so a slow DynamoDB call fails with context.DeadlineExceeded (504)
+// instead of outliving the HTTP write timeout. d <= 0 disables it.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
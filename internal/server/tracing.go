@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/tracing"
+)
+
+// Trace starts a server span for each request, continuing the trace in an
+// incoming W3C traceparent header, and stores it in the request context so
+// handler and DynamoDB spans become its children. The request's logger gains
+// trace_id and span_id. Spans are only exported once tracing.Setup installs
+// a provider.
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			))
+		defer span.End()
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = logging.NewContext(ctx, logging.FromContext(ctx).With(
+				"trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()))
+		}
+		if id := c.GetString(RequestIDKey); id != "" {
+			span.SetAttributes(attribute.StringSlice("http.request.header.x-request-id", []string{id}))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
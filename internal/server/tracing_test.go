@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-serverless-api-terraform/internal/config"
+	"go-serverless-api-terraform/internal/db"
+)
+
+// recordSpans installs a tracer provider and propagator like tracing.Setup,
+// recording spans in memory, and restores the globals after the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+		tp.Shutdown(context.Background())
+	})
+	return rec
+}
+
+// noNetwork answers every DynamoDB call with an empty output instead of sending it.
+func noNetwork(o *dynamodb.Options) {
+	o.Credentials = aws.AnonymousCredentials{}
+	o.APIOptions = append(o.APIOptions, func(s *middleware.Stack) error {
+		return s.Initialize.Add(middleware.InitializeMiddlewareFunc("noNetwork",
+			func(ctx context.Context, in middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				return middleware.InitializeOutput{Result: &dynamodb.GetItemOutput{}}, middleware.Metadata{}, nil
+			}), middleware.After)
+	})
+}
+
+func attr(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTraceSpans(t *testing.T) {
+	rec := recordSpans(t)
+	client, err := db.NewDynamoClient(t.Context(), &config.Config{AWSRegion: "us-east-1"}, noNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Trace())
+	r.GET("/orders/:orderId", func(c *gin.Context) {
+		_, err := client.GetItem(c.Request.Context(), &dynamodb.GetItemInput{
+			TableName: aws.String("orders"),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: c.Param("orderId")}},
+		})
+		if err != nil {
+			t.Errorf("GetItem: %v", err)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/orders/o1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	// An unmatched route still gets a span, named after the method only
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want the DynamoDB span and two server spans", len(spans))
+	}
+	dynamo, server, unmatched := spans[0], spans[1], spans[2]
+
+	if server.Name() != "GET /orders/:orderId" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span = %q (%v), want \"GET /orders/:orderId\" (server)", server.Name(), server.SpanKind())
+	}
+	if got := attr(server.Attributes(), "http.route").AsString(); got != "/orders/:orderId" {
+		t.Errorf("http.route = %q, want /orders/:orderId", got)
+	}
+	if got := attr(server.Attributes(), "http.response.status_code").AsInt64(); got != http.StatusOK {
+		t.Errorf("http.response.status_code = %d, want 200", got)
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("server span trace ID = %s, want %s from traceparent", got, traceID)
+	}
+	if got := server.Parent().SpanID().String(); got != "00f067aa0ba902b7" || !server.Parent().IsRemote() {
+		t.Errorf("server span parent = %s, want the remote 00f067aa0ba902b7", got)
+	}
+
+	if dynamo.Name() != "DynamoDB.GetItem" || dynamo.SpanKind() != trace.SpanKindClient {
+		t.Errorf("DynamoDB span = %q (%v), want \"DynamoDB.GetItem\" (client)", dynamo.Name(), dynamo.SpanKind())
+	}
+	if dynamo.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("DynamoDB span is not a child of the server span")
+	}
+	if got := attr(dynamo.Attributes(), "rpc.method").AsString(); got != "GetItem" {
+		t.Errorf("rpc.method = %q, want GetItem", got)
+	}
+	if got := attr(dynamo.Attributes(), "aws.dynamodb.table_names").AsStringSlice(); len(got) != 1 || got[0] != "orders" {
+		t.Errorf("aws.dynamodb.table_names = %v, want [orders]", got)
+	}
+
+	if unmatched.Name() != "GET" || unmatched.Parent().IsValid() {
+		t.Errorf("unmatched span = %q with parent %v, want a new \"GET\" trace", unmatched.Name(), unmatched.Parent())
+	}
+}
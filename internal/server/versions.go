@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-serverless-api-terraform/internal/http/handlers"
+)
+
+// APIGroups are the route groups of one API version, mounted under the
+// version's prefix. Each group carries its rate limit (see Options.RateLimits);
+// the authentication and tenancy middleware shared by every version is
+// already applied.
+type APIGroups struct {
+	Orders *gin.RouterGroup // "orders" rate limit group
+	Items  *gin.RouterGroup // "items" rate limit group
+	Admin  *gin.RouterGroup // "admin" rate limit group
+
+	// Idempotent adds Idempotency-Key support; use it on POST routes.
+	Idempotent gin.HandlerFunc
+}
+
+// Version registers one API version's routes. A new version can use its own
+// handlers and DTOs over the same repository, e.g.
+//
+//	v2 := handlersv2.New(repo)
+//	opts.Versions = map[string]server.Version{"/v2": func(g server.APIGroups) {
+//		g.Orders.GET("/orders/:orderId", v2.GetOrder)
+//	}}
+type Version func(g APIGroups)
+
+// v1 registers the current API.
+func v1(h *handlers.Handler, keys *handlers.APIKeys) Version {
+	return func(g APIGroups) {
+		// Orders routes
+		g.Orders.GET("/orders", h.ListOrders)
+		g.Orders.POST("/orders", g.Idempotent, h.CreateOrder)
+		g.Orders.GET("/orders/:orderId", h.GetOrder)
+		g.Orders.PUT("/orders/:orderId", h.UpdateOrder)
+		g.Orders.DELETE("/orders/:orderId", h.DeleteOrder)
+		g.Orders.POST("/orders/:orderId/transitions", g.Idempotent, h.TransitionOrder)
+		g.Orders.GET("/orders/:orderId/history", h.GetOrderHistory)
+
+		// Order items routes
+		g.Items.GET("/orders/:orderId/items", h.ListItems)
+		g.Items.POST("/orders/:orderId/items", g.Idempotent, h.CreateItem)
+		g.Items.GET("/orders/:orderId/items/:itemId", h.GetItem)
+		g.Items.PUT("/orders/:orderId/items/:itemId", h.UpdateItem)
+		g.Items.DELETE("/orders/:orderId/items/:itemId", h.DeleteItem)
+
+		// API key administration
+		if keys != nil {
+			g.Admin.GET("/admin/api-keys", keys.List)
+			g.Admin.POST("/admin/api-keys", keys.Create)
+			g.Admin.POST("/admin/api-keys/:keyId/rotate", keys.Rotate)
+			g.Admin.DELETE("/admin/api-keys/:keyId", keys.Revoke)
+		}
+	}
+}
+
+// Deprecation marks legacy responses with the Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers, and links the successor route under prefix.
+// A zero deprecatedAt or sunset omits its header.
+func Deprecation(deprecatedAt, sunset time.Time, prefix string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		if !deprecatedAt.IsZero() {
+			h.Set("Deprecation", deprecation)
+		}
+		if !sunset.IsZero() {
+			h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		h.Add("Link", "<"+prefix+c.Request.URL.Path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecatedAt := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                        string
+		deprecatedAt, sunset        time.Time
+		wantDeprecation, wantSunset string
+	}{
+		{"dates set", deprecatedAt, sunset, "@1792108800", "Fri, 16 Apr 2027 00:00:00 GMT"},
+		{"no dates", time.Time{}, time.Time{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/orders", Deprecation(tt.deprecatedAt, tt.sunset, "/v1"), func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+			if got := w.Header().Get("Deprecation"); got != tt.wantDeprecation {
+				t.Errorf("Deprecation = %q, want %q", got, tt.wantDeprecation)
+			}
+			if got := w.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Sunset = %q, want %q", got, tt.wantSunset)
+			}
+			if got := w.Header().Get("Link"); got != `</v1/orders>; rel="successor-version"` {
+				t.Errorf("Link = %q, want the /v1 successor", got)
+			}
+		})
+	}
+}
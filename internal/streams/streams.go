@@ -0,0 +1,177 @@
+// Package streams handles DynamoDB Streams events from the orders and
+// order_items tables. Each record is decoded into a Change and passed to the
+// registered processors in order.
+package streams
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/models"
+	"go-serverless-api-terraform/internal/repository"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+// Stream event names
+const (
+	Insert = "INSERT"
+	Modify = "MODIFY"
+	Remove = "REMOVE"
+)
+
+// Change is one order or item change from a stream record. Exactly one of the
+// order and item pairs is used, depending on the source table. Old is nil for
+// inserts and New for removes; both are also nil when the stream's view type
+// does not include that image.
+type Change struct {
+	EventID   string
+	EventName string // Insert, Modify or Remove
+	Tenant    string // empty in single-tenant deployments
+
+	OldOrder, NewOrder *models.Order
+	OldItem, NewItem   *models.OrderItem
+}
+
+// IsOrder reports whether c comes from the orders table.
+func (c Change) IsOrder() bool { return c.OldOrder != nil || c.NewOrder != nil }
+
+// OrderID returns the ID of the changed order, or of the changed item's order.
+func (c Change) OrderID() string {
+	switch {
+	case c.NewOrder != nil:
+		return c.NewOrder.ID
+	case c.OldOrder != nil:
+		return c.OldOrder.ID
+	case c.NewItem != nil:
+		return c.NewItem.OrderID
+	case c.OldItem != nil:
+		return c.OldItem.OrderID
+	}
+	return ""
+}
+
+// Processor handles changes. Processors must be idempotent: a failed record
+// is retried, together with the records after it in its shard.
+type Processor interface {
+	Process(ctx context.Context, c Change) error
+}
+
+// ProcessorFunc adapts a function to Processor.
+type ProcessorFunc func(ctx context.Context, c Change) error
+
+func (f ProcessorFunc) Process(ctx context.Context, c Change) error { return f(ctx, c) }
+
+type registered struct {
+	name string
+	p    Processor
+}
+
+// Dispatcher routes stream records to processors.
+type Dispatcher struct {
+	ordersTable     string
+	orderItemsTable string
+	processors      []registered
+}
+
+func NewDispatcher(ordersTable, orderItemsTable string) *Dispatcher {
+	return &Dispatcher{ordersTable: ordersTable, orderItemsTable: orderItemsTable}
+}
+
+// Register adds p; name identifies it in logs.
+func (d *Dispatcher) Register(name string, p Processor) {
+	d.processors = append(d.processors, registered{name: name, p: p})
+}
+
+// Handle processes the records of ev in order. It stops at the first record
+// that cannot be decoded or processed and reports it, and with it the rest of
+// the batch, as a batch item failure (the event source mapping must enable
+// ReportBatchItemFailures). Lambda then retries from that record, so records
+// of one order are never processed out of order.
+func (d *Dispatcher) Handle(ctx context.Context, ev events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	log := logging.FromContext(ctx)
+	var res events.DynamoDBEventResponse
+	for i, rec := range ev.Records {
+		if err := d.handleRecord(ctx, rec); err != nil {
+			log.ErrorContext(ctx, "stream record failed", "event_id", rec.EventID,
+				"sequence_number", rec.Change.SequenceNumber, "error", err)
+			for _, r := range ev.Records[i:] {
+				res.BatchItemFailures = append(res.BatchItemFailures,
+					events.DynamoDBBatchItemFailure{ItemIdentifier: r.Change.SequenceNumber})
+			}
+			break
+		}
+	}
+	return res, nil
+}
+
+func (d *Dispatcher) handleRecord(ctx context.Context, rec events.DynamoDBEventRecord) error {
+	c, err := d.decode(rec)
+	if err != nil {
+		return err
+	}
+	ctx = tenant.NewContext(ctx, c.Tenant)
+	for _, p := range d.processors {
+		if err := p.p.Process(ctx, c); err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) decode(rec events.DynamoDBEventRecord) (Change, error) {
+	c := Change{EventID: rec.EventID, EventName: rec.EventName}
+	var err error
+	switch table := tableName(rec.EventSourceArn); table {
+	case d.ordersTable:
+		if c.OldOrder, err = decodeImage(rec.Change.OldImage, repository.DecodeOrder, &c.Tenant); err != nil {
+			return c, fmt.Errorf("decode old order: %w", err)
+		}
+		if c.NewOrder, err = decodeImage(rec.Change.NewImage, repository.DecodeOrder, &c.Tenant); err != nil {
+			return c, fmt.Errorf("decode new order: %w", err)
+		}
+	case d.orderItemsTable:
+		if c.OldItem, err = decodeImage(rec.Change.OldImage, repository.DecodeOrderItem, &c.Tenant); err != nil {
+			return c, fmt.Errorf("decode old item: %w", err)
+		}
+		if c.NewItem, err = decodeImage(rec.Change.NewImage, repository.DecodeOrderItem, &c.Tenant); err != nil {
+			return c, fmt.Errorf("decode new item: %w", err)
+		}
+	default:
+		return c, fmt.Errorf("record from unknown table %q", table)
+	}
+	return c, nil
+}
+
+// decodeImage decodes a stream image, returning nil for a missing one, and
+// stores the tenant of a present one in tenantID.
+func decodeImage[T any](img map[string]events.DynamoDBAttributeValue, decode func(map[string]types.AttributeValue) (*T, string, error), tenantID *string) (*T, error) {
+	if len(img) == 0 {
+		return nil, nil
+	}
+	item, err := attributeMap(img)
+	if err != nil {
+		return nil, err
+	}
+	v, t, err := decode(item)
+	if err != nil {
+		return nil, err
+	}
+	*tenantID = t
+	return v, nil
+}
+
+// tableName extracts the table from a stream ARN such as
+// arn:aws:dynamodb:us-east-1:123456789012:table/orders/stream/2024-01-01T00:00:00.000.
+func tableName(arn string) string {
+	_, rest, ok := strings.Cut(arn, ":table/")
+	if !ok {
+		return ""
+	}
+	table, _, _ := strings.Cut(rest, "/")
+	return table
+}
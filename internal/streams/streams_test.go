@@ -0,0 +1,138 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go-serverless-api-terraform/internal/repository"
+	"go-serverless-api-terraform/internal/tenant"
+)
+
+const (
+	ordersARN = "arn:aws:dynamodb:us-east-1:123456789012:table/orders/stream/2026-01-01T00:00:00.000"
+	itemsARN  = "arn:aws:dynamodb:us-east-1:123456789012:table/order_items/stream/2026-01-01T00:00:00.000"
+)
+
+func itemImage(tenantID, orderID, id string) map[string]events.DynamoDBAttributeValue {
+	img := map[string]events.DynamoDBAttributeValue{
+		"order_id": events.NewStringAttribute(orderID),
+		"id":       events.NewStringAttribute(id),
+		"quantity": events.NewNumberAttribute("2"),
+	}
+	if tenantID != "" {
+		img["order_id"] = events.NewStringAttribute(tenantID + tenant.Separator + orderID)
+		img["tenant_id"] = events.NewStringAttribute(tenantID)
+	}
+	return img
+}
+
+func record(seq, arn string, img map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID:        "ev" + seq,
+		EventName:      Insert,
+		EventSourceArn: arn,
+		Change:         events.DynamoDBStreamRecord{SequenceNumber: seq, NewImage: img},
+	}
+}
+
+func TestHandleReportsFirstFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []events.DynamoDBEventRecord
+		want    string
+		handled string
+	}{
+		{
+			name: "all processed",
+			records: []events.DynamoDBEventRecord{
+				record("100", itemsARN, itemImage("acme", "o1", "i1")),
+				record("200", itemsARN, itemImage("", "o2", "i1")),
+			},
+			want: "[]", handled: "[acme/o1 /o2]",
+		},
+		{
+			name: "processor fails",
+			records: []events.DynamoDBEventRecord{
+				record("100", itemsARN, itemImage("acme", "o1", "i1")),
+				record("200", itemsARN, itemImage("acme", "fail", "i1")),
+				record("300", itemsARN, itemImage("acme", "o3", "i1")),
+			},
+			want: "[200 300]", handled: "[acme/o1 acme/fail]",
+		},
+		{
+			name: "unknown table",
+			records: []events.DynamoDBEventRecord{
+				record("100", "arn:aws:dynamodb:us-east-1:123456789012:table/other/stream/x", itemImage("", "o1", "i1")),
+				record("200", itemsARN, itemImage("", "o2", "i1")),
+			},
+			want: "[100 200]", handled: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled []string
+			d := NewDispatcher("orders", "order_items")
+			d.Register("test", ProcessorFunc(func(ctx context.Context, c Change) error {
+				handled = append(handled, tenant.FromContext(ctx)+"/"+c.OrderID())
+				if c.OrderID() == "fail" {
+					return errors.New("rejected")
+				}
+				return nil
+			}))
+			res, err := d.Handle(context.Background(), events.DynamoDBEvent{Records: tt.records})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var failed []string
+			for _, f := range res.BatchItemFailures {
+				failed = append(failed, f.ItemIdentifier)
+			}
+			if got := fmt.Sprint(failed); got != tt.want {
+				t.Errorf("batch item failures = %s, want %s", got, tt.want)
+			}
+			if got := fmt.Sprint(handled); got != tt.handled {
+				t.Errorf("processed %s, want %s", got, tt.handled)
+			}
+		})
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var tenantID string
+	it, err := decodeImage(itemImage("acme", "o1", "i1"), repository.DecodeOrderItem, &tenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenantID != "acme" || it.OrderID != "o1" || it.ID != "i1" || it.Quantity != 2 {
+		t.Errorf("decoded %+v for tenant %q, want item i1 of o1 for acme", it, tenantID)
+	}
+
+	tenantID = ""
+	if it, err := decodeImage(itemImage("", "o1", "i1"), repository.DecodeOrderItem, &tenantID); err != nil || tenantID != "" || it.OrderID != "o1" {
+		t.Errorf("unscoped image: %+v, %q, %v; want o1 without a tenant", it, tenantID, err)
+	}
+
+	tenantID = "acme"
+	if o, err := decodeImage(nil, repository.DecodeOrder, &tenantID); o != nil || err != nil || tenantID != "acme" {
+		t.Errorf("missing image: %v, %v, tenant %q; want nil and the tenant untouched", o, err, tenantID)
+	}
+}
+
+func TestTableName(t *testing.T) {
+	tests := map[string]string{
+		ordersARN: "orders",
+		itemsARN:  "order_items",
+		"arn:aws:dynamodb:us-east-1:123456789012:table/orders": "orders",
+		"arn:aws:sqs:us-east-1:123456789012:queue":             "",
+		"": "",
+	}
+	for arn, want := range tests {
+		if got := tableName(arn); got != want {
+			t.Errorf("tableName(%q) = %q, want %q", arn, got, want)
+		}
+	}
+}
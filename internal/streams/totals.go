@@ -0,0 +1,34 @@
+package streams
+
+import (
+	"context"
+
+	"go-serverless-api-terraform/internal/logging"
+)
+
+// TotalsRepairer recomputes an order's totals from its items.
+// *repository.DynamoRepository implements it.
+type TotalsRepairer interface {
+	RepairTotals(ctx context.Context, orderID string) (bool, error)
+}
+
+// Totals returns a processor that recomputes the order's totals after every
+// item change. The API already keeps totals in step transactionally, so this
+// only repairs drift left by writes that bypassed it.
+func Totals(repo TotalsRepairer) Processor {
+	return ProcessorFunc(func(ctx context.Context, c Change) error {
+		if c.IsOrder() {
+			return nil
+		}
+		// ErrVersionMismatch fails the record too, so it is retried against
+		// the order's new version.
+		repaired, err := repo.RepairTotals(ctx, c.OrderID())
+		if err != nil {
+			return err
+		}
+		if repaired {
+			logging.FromContext(ctx).InfoContext(ctx, "order totals repaired", "order_id", c.OrderID())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolution errors.
+var (
+	ErrMissing  = errors.New("tenant not specified")
+	ErrInvalid  = errors.New("invalid tenant id")
+	ErrMismatch = errors.New("tenant does not match the caller's tenant")
+	ErrUnbound  = errors.New("caller is not bound to a tenant")
+)
+
+// Resolver determines a request's tenant. The authenticated caller's tenant
+// (from a token claim or API key) is authoritative; a header or subdomain
+// naming a different tenant is rejected. Only callers allowed to choose a
+// tenant may go without one; for them the Header is used, then the subdomain
+// of BaseDomain ("acme.shop.example.com" -> "acme").
+type Resolver struct {
+	Header     string // e.g. "X-Tenant-ID"; empty disables
+	BaseDomain string // e.g. "shop.example.com"; empty disables
+}
+
+// Resolve returns the tenant for r. callerTenant is the tenant bound to the
+// authenticated caller, or "" if none; mayChoose reports whether a caller
+// without one may pick any tenant.
+func (res *Resolver) Resolve(r *http.Request, callerTenant string, mayChoose bool) (string, error) {
+	requested := ""
+	if res.Header != "" {
+		requested = strings.TrimSpace(r.Header.Get(res.Header))
+	}
+	if requested == "" {
+		requested = res.subdomain(r.Host)
+	}
+	switch {
+	case callerTenant != "" && requested != "" && requested != callerTenant:
+		return "", ErrMismatch
+	case callerTenant != "":
+		requested = callerTenant
+	case !mayChoose:
+		return "", ErrUnbound
+	case requested == "":
+		return "", ErrMissing
+	}
+	if !Valid(requested) {
+		return "", ErrInvalid
+	}
+	return requested, nil
+}
+
+func (res *Resolver) subdomain(host string) string {
+	if res.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(res.BaseDomain))
+	if !ok || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
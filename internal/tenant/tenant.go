@@ -0,0 +1,32 @@
+// Package tenant carries the storefront (tenant) a request belongs to.
+//
+// Storage keeps tenants apart by prefixing keys with the tenant ID and
+// Separator, so IDs are restricted to characters that cannot contain it.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Separator joins a tenant ID and a key value in storage.
+const Separator = "#"
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Valid reports whether id is a well-formed tenant ID: 1-63 lowercase
+// letters, digits, '-' or '_', starting with a letter or digit.
+func Valid(id string) bool { return validID.MatchString(id) }
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx scoped to tenant id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to, or "" for none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,65 @@
+// Package tracing configures OpenTelemetry tracing and W3C Trace Context
+// propagation.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name identifies this service's instrumentation.
+const Name = "go-serverless-api-terraform"
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp" // OTLP/HTTP; endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables
+)
+
+// Setup installs the W3C traceparent/baggage propagator and, unless exporter
+// is ExporterNone, a global tracer provider exporting to exporter. It returns
+// the provider, or nil when spans are not exported; callers flush or shut it
+// down before exiting.
+func Setup(ctx context.Context, exporter, serviceName string) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+	switch exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case ExporterOTLP:
+		exp, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q: want otlp, stdout or none", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp, nil
+}
+
+// Tracer returns the tracer for this service from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
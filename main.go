@@ -2,17 +2,35 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
+	"github.com/gin-gonic/gin"
 
+	"go-serverless-api-terraform/internal/apikeys"
+	"go-serverless-api-terraform/internal/auth"
 	"go-serverless-api-terraform/internal/config"
 	"go-serverless-api-terraform/internal/db"
+	"go-serverless-api-terraform/internal/health"
 	"go-serverless-api-terraform/internal/http/handlers"
+	"go-serverless-api-terraform/internal/idempotency"
+	"go-serverless-api-terraform/internal/logging"
+	"go-serverless-api-terraform/internal/metrics"
+	"go-serverless-api-terraform/internal/outbox"
+	"go-serverless-api-terraform/internal/ratelimit"
 	"go-serverless-api-terraform/internal/repository"
 	"go-serverless-api-terraform/internal/server"
+	"go-serverless-api-terraform/internal/streams"
+	"go-serverless-api-terraform/internal/tenant"
+	"go-serverless-api-terraform/internal/tracing"
 )
 
 // @title Orders API
@@ -22,21 +40,23 @@ import (
 // @schemes http https
 // @accept json
 // @produce json
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @security BearerAuth
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		fatal("failed to load config", err)
 	}
-
-	ctx := context.Background()
-	dynamo, err := db.NewDynamoClient(ctx, cfg)
+	logger, err := logging.New(os.Stdout, cfg.LogLevel)
 	if err != nil {
-		log.Fatalf("failed to create dynamodb client: %v", err)
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+	if !strings.EqualFold(cfg.LogLevel, "debug") {
+		gin.SetMode(gin.ReleaseMode)
 	}
-
-	repo := repository.NewDynamoRepository(dynamo, cfg.OrdersTable, cfg.OrderItemsTable)
-	h := handlers.New(repo)
-	r := server.NewRouter(h)
 
 	// Determine run mode: default local, otherwise Lambda
 	env := cfg.Env
@@ -44,17 +64,224 @@ func main() {
 		env = os.Getenv("APP_ENV")
 	}
 
+	// Metrics are scraped from /metrics locally; a Lambda function can't be
+	// scraped, so there they are written to stdout as EMF for CloudWatch.
+	var (
+		rec         metrics.Recorder
+		metricsHTTP http.Handler
+		dynamoOpts  []func(*dynamodb.Options)
+	)
+	if cfg.MetricsEnabled {
+		if env == "local" {
+			prom := metrics.NewPrometheus()
+			rec, metricsHTTP = prom, prom.Handler()
+		} else {
+			rec = metrics.NewEMF(os.Stdout, cfg.MetricsNamespace)
+		}
+		dynamoOpts = append(dynamoOpts, db.WithMetrics(rec))
+	}
+
+	ctx := context.Background()
+	tp, err := tracing.Setup(ctx, cfg.TraceExporter, cfg.ServiceName)
+	if err != nil {
+		fatal("failed to configure tracing", err)
+	}
+
+	var (
+		repo       repository.Repository
+		idem       idempotency.Store
+		keys       apikeys.Store
+		checks     []health.Checker
+		limit      ratelimit.Limiter
+		pending    outbox.Store // unpublished domain events
+		dynamoRepo *repository.DynamoRepository
+	)
+	if cfg.Storage == "memory" {
+		slog.Warn("using in-memory storage; data is lost on restart")
+		var ob *outbox.MemoryStore
+		if cfg.OutboxEnabled {
+			ob = outbox.NewMemoryStore()
+			pending = ob
+		}
+		repo = repository.NewMemoryRepository(ob)
+		idem = idempotency.NewMemoryStore()
+		keys = apikeys.NewMemoryStore()
+	} else {
+		dynamo, err := db.NewDynamoClient(ctx, cfg, dynamoOpts...)
+		if err != nil {
+			fatal("failed to create dynamodb client", err)
+		}
+		outboxTable := ""
+		if cfg.OutboxEnabled {
+			outboxTable = cfg.OutboxTable
+			pending = outbox.NewDynamoStore(dynamo, cfg.OutboxTable)
+		}
+		dynamoRepo = repository.NewDynamoRepository(dynamo, cfg.OrdersTable, cfg.OrderItemsTable, cfg.OrderEventsTable, outboxTable)
+		repo = dynamoRepo
+		idem = idempotency.NewDynamoStore(dynamo, cfg.IdempotencyTable)
+		keys = apikeys.NewDynamoStore(dynamo, cfg.APIKeysTable)
+		if cfg.RateLimitBackend == "dynamo" {
+			limit = ratelimit.NewDynamoLimiter(dynamo, cfg.RateLimitsTable)
+		}
+		checks = []health.Checker{
+			health.DynamoTable{DB: dynamo, Table: cfg.OrdersTable},
+			health.DynamoTable{DB: dynamo, Table: cfg.OrderItemsTable},
+			health.DynamoTable{DB: dynamo, Table: cfg.OrderEventsTable},
+		}
+		if cfg.OutboxEnabled {
+			checks = append(checks, health.DynamoTable{DB: dynamo, Table: cfg.OutboxTable})
+		}
+	}
+	if cfg.RateLimitBackend == "memory" {
+		limit = ratelimit.NewMemoryLimiter()
+	}
+	if !cfg.APIKeysEnabled {
+		keys = nil
+	}
+
+	// Lambda mode (DynamoDB Streams of the orders and order_items tables)
+	if env != "local" && cfg.LambdaHandler == "streams" {
+		slog.Info("starting in Lambda streams mode", "env", env)
+		d := streams.NewDispatcher(cfg.OrdersTable, cfg.OrderItemsTable)
+		d.Register("totals", streams.Totals(dynamoRepo))
+		lambda.Start(func(ctx context.Context, ev events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+			res, err := d.Handle(ctx, ev)
+			if tp != nil {
+				if ferr := tp.ForceFlush(ctx); ferr != nil {
+					slog.WarnContext(ctx, "failed to export spans", "error", ferr)
+				}
+			}
+			return res, err
+		})
+		return
+	}
+
+	authCfg := auth.Config{
+		JWKSFile:    cfg.JWKSFile,
+		JWKSURL:     cfg.JWKSURL,
+		HS256Secret: []byte(cfg.JWTHS256Secret),
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		RolesClaim:  cfg.JWTRolesClaim,
+		TenantClaim: cfg.JWTTenantClaim,
+	}
+	var verifier *auth.Verifier
+	if authCfg.Enabled() {
+		if verifier, err = auth.NewVerifier(ctx, authCfg); err != nil {
+			fatal("failed to configure authentication", err)
+		}
+	} else if !cfg.APIKeysEnabled {
+		slog.Warn("no JWKS, secret or API keys configured; authentication is disabled")
+	}
+
+	var tenants *tenant.Resolver
+	if cfg.TenancyEnabled {
+		tenants = &tenant.Resolver{Header: cfg.TenantHeader, BaseDomain: cfg.TenantBaseDomain}
+	}
+
+	h := handlers.New(repo)
+	r := server.NewRouter(h, server.Options{
+		Idempotency:      idem,
+		IdempotencyTTL:   cfg.IdempotencyTTL,
+		IdempotencyLease: cfg.IdempotencyLease,
+		Auth:             verifier,
+		AuthExemptPaths:  cfg.AuthExemptPaths,
+		APIKeys:          keys,
+		APIKeyCacheTTL:   cfg.APIKeyCacheTTL,
+		Tenants:          tenants,
+		Logger:           logger,
+		Metrics:          rec,
+		MetricsHandler:   metricsHTTP,
+		Readiness:        health.NewReadiness(cfg.ReadinessTimeout, cfg.ReadinessCacheTTL, checks...),
+		RequestTimeout:   cfg.RequestTimeout,
+		RateLimiter:      limit,
+		RateLimits:       cfg.RateLimits,
+		TrustedProxies:   cfg.TrustedProxies,
+		CORS:             corsConfig(cfg),
+		Legacy:           legacyRoutes(cfg),
+	})
+
+	// The relay runs in process locally; in Lambda mode cmd/outbox-relay does.
+	var relay *outbox.Relay
 	if env == "local" {
-		addr := ":" + cfg.Port
-		log.Printf("listening on %s", addr)
-		if err := r.Run(addr); err != nil {
-			log.Fatalf("server stopped: %v", err)
+		pub, err := outbox.NewPublisher(ctx, cfg)
+		if err != nil {
+			fatal("failed to configure outbox publisher", err)
+		}
+		if pub != nil {
+			relay = outbox.NewRelay(pending, pub, cfg.OutboxPollInterval, cfg.OutboxMaxAttempts)
+		}
+	}
+
+	if env == "local" {
+		srv := &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           r,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		}
+		sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if relay != nil {
+			go relay.Run(sigCtx)
+		}
+		slog.Info("listening", "addr", srv.Addr)
+		err := server.Serve(sigCtx, srv, cfg.ShutdownTimeout)
+		if tp != nil {
+			_ = tp.Shutdown(ctx)
 		}
+		if err != nil {
+			fatal("server stopped", err)
+		}
+		slog.Info("server stopped")
 		return
 	}
 
 	// Lambda mode (API Gateway / ALB)
-	log.Printf("starting in Lambda mode (env=%s)", env)
+	slog.Info("starting in Lambda mode", "env", env)
 	adapter := ginadapter.New(r)
-	lambda.Start(adapter.ProxyWithContext)
+	if tp == nil {
+		lambda.Start(adapter.ProxyWithContext)
+		return
+	}
+	// The execution environment may be frozen right after the response, so
+	// export each invocation's spans before returning it.
+	lambda.Start(func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		res, err := adapter.ProxyWithContext(ctx, req)
+		if ferr := tp.ForceFlush(ctx); ferr != nil {
+			slog.WarnContext(ctx, "failed to export spans", "error", ferr)
+		}
+		return res, err
+	})
+}
+
+// corsConfig returns the CORS settings, or nil when no origins are allowed.
+func corsConfig(cfg *config.Config) *server.CORSConfig {
+	if len(cfg.CORSAllowedOrigins) == 0 {
+		return nil
+	}
+	return &server.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
+
+// legacyRoutes returns the deprecation of the unversioned routes, or nil when they are disabled.
+func legacyRoutes(cfg *config.Config) *server.LegacyRoutes {
+	if !cfg.LegacyRoutesEnabled {
+		return nil
+	}
+	return &server.LegacyRoutes{DeprecatedAt: cfg.LegacyRoutesDeprecatedAt, Sunset: cfg.LegacyRoutesSunset}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }